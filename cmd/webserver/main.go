@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
@@ -10,15 +11,21 @@ import (
 	"time"
 
 	"example.com/m/v2/internal/api"
+	"example.com/m/v2/internal/auth"
 	"example.com/m/v2/internal/config"
 	"example.com/m/v2/internal/database"
+	"example.com/m/v2/internal/ratelimit"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
+	"example.com/m/v2/internal/scheduler"
 	"example.com/m/v2/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	configPath = flag.String("config", "config/config.yaml", "配置文件路径")
-	port       = flag.String("port", "8080", "服务器端口")
+	configPath  = flag.String("config", "config/config.yaml", "配置文件路径")
+	port        = flag.String("port", "8080", "服务器端口")
+	skipMigrate = flag.Bool("skip-migrate", false, "启动时不自动执行数据库迁移")
 )
 
 func main() {
@@ -41,11 +48,46 @@ func main() {
 	}
 	defer db.Close()
 
-	// 初始化数据库表
-	if err := database.InitTables(db); err != nil {
-		log.Fatalf("初始化数据库表失败: %v", err)
+	// migrate 子命令只执行迁移，不启动服务
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(db, flag.Args()[1:]); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	}
+
+	// 自动执行数据库迁移
+	if !*skipMigrate {
+		applied, err := database.Migrate(db)
+		if err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		for _, m := range applied {
+			logger.Info("已应用数据库迁移", "version", m.Version, "name", m.Name)
+		}
+	}
+
+	repos := repository.NewSQLRepositories(db)
+
+	// user 子命令管理用户，在迁移之后执行，不启动服务
+	if flag.Arg(0) == "user" {
+		if err := runUser(repos, flag.Args()[1:]); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	// 创建任务执行器
+	taskRunner := runner.NewRunner(repos, cfg, logger)
+	if err := taskRunner.RecoverInterrupted(); err != nil {
+		logger.Error("恢复中断任务失败", "error", err)
 	}
 
+	// 启动站点调度器
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go scheduler.NewScheduler(repos.Sites, taskRunner, logger).Run(schedulerCtx)
+
 	// 设置Gin模式，与日志级别关联
 	if cfg.Logging.Level == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -57,7 +99,7 @@ func main() {
 	router := gin.New()
 
 	// 添加中间件
-	router.Use(gin.Logger())
+	router.Use(gin.LoggerWithFormatter(api.RequestLogFormatter))
 	router.Use(gin.Recovery())
 	router.Use(api.CORSMiddleware())
 	router.Use(api.LoggerMiddleware(logger))
@@ -67,9 +109,27 @@ func main() {
 	router.StaticFile("/", "./web/build/index.html")
 	router.StaticFile("/favicon.ico", "./web/build/favicon.ico")
 
+	// 启用认证时创建令牌管理器，没有用户时创建初始管理员
+	var tokens *auth.Manager
+	if cfg.Web.Auth.Enable {
+		tokens, err = auth.NewManager(cfg.Web.Auth)
+		if err != nil {
+			log.Fatalf("初始化认证失败: %v", err)
+		}
+		if err := ensureAdminUser(repos.Users, cfg.Web.Auth, logger, os.Stderr); err != nil {
+			log.Fatalf("创建初始管理员失败: %v", err)
+		}
+	}
+
+	// 启用限流时使用进程内的令牌桶存储，多实例部署可替换为共享存储
+	var limiter ratelimit.Store
+	if cfg.Web.RateLimit.Enable {
+		limiter = ratelimit.NewMemoryStore()
+	}
+
 	// API路由
 	apiGroup := router.Group("/api/v1")
-	api.SetupRoutes(apiGroup, db, logger, cfg)
+	api.SetupRoutes(apiGroup, repos, logger, cfg, taskRunner, tokens, limiter)
 
 	// 启动服务器
 	server := &http.Server{
@@ -86,6 +146,17 @@ func main() {
 		<-sigterm
 
 		logger.Info("正在关闭Web服务器...")
+
+		// 停止调度，避免关闭过程中启动新任务
+		stopScheduler()
+
+		// 先停止运行中的任务，保证任务状态写回数据库
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := taskRunner.Shutdown(ctx); err != nil {
+			logger.Error("停止运行中的任务超时", "error", err)
+		}
+
 		if err := server.Close(); err != nil {
 			logger.Error("关闭服务器失败", "error", err)
 		}
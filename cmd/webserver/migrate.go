@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/internal/migrations"
+)
+
+// migrateUsage migrate 子命令用法
+const migrateUsage = `用法: webserver migrate <命令>
+
+命令:
+  status    查看迁移状态
+  up        应用全部未应用的迁移
+  down      回滚最近一次迁移
+  to <N>    应用或回滚到版本 N（0 表示回滚全部）`
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少迁移命令\n%s", migrateUsage)
+	}
+
+	// 与启动时的自动迁移一致，应用迁移前先整理旧版数据库
+	if args[0] == "up" || args[0] == "to" {
+		if err := database.AdoptLegacySchema(db); err != nil {
+			return err
+		}
+	}
+
+	migrator, err := migrations.NewMigrator(db.DB, db.Dialect.Driver())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "未应用"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s  %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+
+	case "up":
+		applied, err := migrator.Up()
+		printMigrations("已应用", applied)
+		return err
+
+	case "down":
+		rolledBack, err := migrator.Down()
+		if rolledBack != nil {
+			printMigrations("已回滚", []migrations.Migration{*rolledBack})
+		} else if err == nil {
+			fmt.Println("没有可回滚的迁移")
+		}
+		return err
+
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("缺少目标版本\n%s", migrateUsage)
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("无效的目标版本: %s", args[1])
+		}
+		executed, err := migrator.To(target)
+		printMigrations("已执行", executed)
+		return err
+
+	default:
+		return fmt.Errorf("未知的迁移命令: %s\n%s", args[0], migrateUsage)
+	}
+}
+
+// printMigrations 输出执行过的迁移
+func printMigrations(action string, executed []migrations.Migration) {
+	if len(executed) == 0 {
+		fmt.Println("数据库已是目标版本")
+		return
+	}
+	for _, m := range executed {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
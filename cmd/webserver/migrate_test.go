@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/internal/migrations"
+)
+
+func TestRunMigrate(t *testing.T) {
+	db, err := database.NewConnection(config.DBConfig{Driver: "sqlite3", SQLiteFile: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db.DB, db.Dialect.Driver())
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	version := func() int {
+		t.Helper()
+		v, err := migrator.Version()
+		if err != nil {
+			t.Fatalf("查询版本失败: %v", err)
+		}
+		return v
+	}
+
+	steps := []struct {
+		args    []string
+		version int // 为 -1 时期望失败
+	}{
+		{[]string{"up"}, migrator.Latest()},
+		{[]string{"status"}, migrator.Latest()},
+		{[]string{"down"}, migrator.Latest() - 1},
+		{[]string{"to", "0"}, 0},
+		{[]string{"to", "1"}, 1},
+		{[]string{"up"}, migrator.Latest()},
+		{[]string{"to"}, -1},
+		{[]string{"to", "x"}, -1},
+		{[]string{"sideways"}, -1},
+		{nil, -1},
+	}
+	for _, step := range steps {
+		err := runMigrate(db, step.args)
+		if step.version < 0 {
+			if err == nil {
+				t.Errorf("migrate %v 应返回错误", step.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("migrate %v 失败: %v", step.args, err)
+		}
+		if v := version(); v != step.version {
+			t.Fatalf("migrate %v 后版本为 %d，期望 %d", step.args, v, step.version)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/utils"
+)
+
+// userUsage user 子命令用法
+const userUsage = `用法: webserver user <命令>
+
+命令:
+  create <用户名> <密码> [角色]    创建用户，角色默认为 viewer
+  role <用户名> <角色>             修改用户角色
+
+角色: viewer, operator, editor, admin`
+
+// runUser 执行 user 子命令
+func runUser(repos *repository.Repositories, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少用户命令\n%s", userUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) < 3 {
+			return fmt.Errorf("缺少用户名或密码\n%s", userUsage)
+		}
+		role := auth.RoleViewer
+		if len(args) > 3 {
+			role = args[3]
+		}
+		user, err := createUser(repos.Users, args[1], args[2], role)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已创建用户 %s (ID %d, 角色 %s)\n", user.Username, user.ID, user.Role)
+		return nil
+
+	case "role":
+		if len(args) < 3 {
+			return fmt.Errorf("缺少用户名或角色\n%s", userUsage)
+		}
+		if !auth.ValidRole(args[2]) {
+			return fmt.Errorf("未知的角色: %s", args[2])
+		}
+		user, err := repos.Users.GetByUsername(args[1])
+		if err != nil {
+			return fmt.Errorf("查询用户 %s 失败: %w", args[1], err)
+		}
+		user.Role = args[2]
+		if err := repos.Users.Update(user); err != nil {
+			return fmt.Errorf("更新用户失败: %w", err)
+		}
+		fmt.Printf("已将用户 %s 的角色修改为 %s\n", user.Username, user.Role)
+		return nil
+
+	default:
+		return fmt.Errorf("未知的用户命令: %s\n%s", args[0], userUsage)
+	}
+}
+
+// createUser 创建启用状态的用户
+func createUser(users repository.UserRepository, username, password, role string) (*repository.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("用户名不能为空")
+	}
+	if !auth.ValidRole(role) {
+		return nil, fmt.Errorf("未知的角色: %s", role)
+	}
+	if _, err := users.GetByUsername(username); err == nil {
+		return nil, fmt.Errorf("用户名已存在: %s", username)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := &repository.User{Username: username, PasswordHash: hash, Role: role, Enabled: true}
+	if err := users.Create(user); err != nil {
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+	return user, nil
+}
+
+// ensureAdminUser 没有任何用户时按配置创建初始管理员。未配置密码时随机生成，
+// 只向 out（通常为标准错误）输出一次，不写入日志文件
+func ensureAdminUser(users repository.UserRepository, cfg config.AuthConfig, logger utils.Logger, out io.Writer) error {
+	count, err := users.Count()
+	if err != nil || count > 0 {
+		return err
+	}
+
+	password := cfg.AdminPassword
+	generated := password == ""
+	if generated {
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("生成初始密码失败: %w", err)
+		}
+		password = base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	user, err := createUser(users, cfg.AdminUsername, password, auth.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if generated {
+		fmt.Fprintf(out, "已创建初始管理员 %s，密码: %s\n请登录后修改密码，此密码不会再次显示\n", user.Username, password)
+		logger.Warn("已创建初始管理员，随机生成的密码已输出到标准错误", "username", user.Username)
+	} else {
+		logger.Info("已创建初始管理员", "username", user.Username)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/repository"
+)
+
+// recordLogger 记录全部日志内容
+type recordLogger struct {
+	b strings.Builder
+}
+
+func (l *recordLogger) log(msg string, args ...interface{}) {
+	fmt.Fprintln(&l.b, msg, args)
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.log(msg, args...) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.log(msg, args...) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.log(msg, args...) }
+
+func TestEnsureAdminUserKeepsPasswordOutOfLogs(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	logger := &recordLogger{}
+	var out bytes.Buffer
+	cfg := config.AuthConfig{AdminUsername: "admin"}
+
+	if err := ensureAdminUser(repos.Users, cfg, logger, &out); err != nil {
+		t.Fatalf("创建初始管理员失败: %v", err)
+	}
+
+	_, password, ok := strings.Cut(strings.SplitN(out.String(), "\n", 2)[0], "密码: ")
+	if !ok || password == "" {
+		t.Fatalf("未输出初始密码: %q", out.String())
+	}
+	if strings.Contains(logger.b.String(), password) {
+		t.Fatalf("日志中包含初始密码: %s", logger.b.String())
+	}
+	user, err := repos.Users.GetByUsername("admin")
+	if err != nil || user.Role != auth.RoleAdmin || !auth.CheckPassword(user.PasswordHash, password) {
+		t.Fatalf("管理员 = %+v，err = %v", user, err)
+	}
+
+	// 已有用户时不再创建，也不再输出密码
+	out.Reset()
+	if err := ensureAdminUser(repos.Users, cfg, logger, &out); err != nil || out.Len() > 0 {
+		t.Fatalf("重复创建: err=%v 输出 %q", err, out.String())
+	}
+}
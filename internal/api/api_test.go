@@ -0,0 +1,176 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
+	"example.com/m/v2/pkg/constants"
+	"github.com/gin-gonic/gin"
+)
+
+// testPassword 测试用户的密码
+const testPassword = "password1"
+
+// testLogger 将日志写入测试输出，只在测试失败或 -v 时显示
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Info(msg string, kv ...interface{})  { l.log("INFO", msg, kv) }
+func (l testLogger) Error(msg string, kv ...interface{}) { l.log("ERROR", msg, kv) }
+func (l testLogger) Warn(msg string, kv ...interface{})  { l.log("WARN", msg, kv) }
+func (l testLogger) Debug(msg string, kv ...interface{}) { l.log("DEBUG", msg, kv) }
+
+func (l testLogger) log(level, msg string, kv []interface{}) {
+	l.t.Helper()
+	l.t.Logf("[%s] %s %v", level, msg, kv)
+}
+
+// testServer 使用内存存取接口的API服务
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	repos  *repository.Repositories
+	runner *runner.Runner
+}
+
+// newTestServer 创建测试服务，withAuth 为 true 时启用认证
+func newTestServer(t *testing.T, withAuth bool) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	var tokens *auth.Manager
+	if withAuth {
+		cfg.Web.Auth = config.AuthConfig{Enable: true, JWTSecret: "test-secret-0123456789abcdefghijklmn", TokenExpire: 1, RefreshExpire: 24}
+		var err error
+		if tokens, err = auth.NewManager(cfg.Web.Auth); err != nil {
+			t.Fatalf("创建令牌管理器失败: %v", err)
+		}
+	}
+
+	// 任务数据写入临时目录的 JSONL 文件
+	cfg.Spider = config.SpiderConfig{Concurrent: 1, Timeout: 5, UserAgent: "test-agent"}
+	cfg.Storage = config.StorageConfig{Type: constants.StorageTypeJSON, OutputDir: t.TempDir()}
+
+	repos := repository.NewMemoryRepositories()
+	taskRunner := runner.NewRunner(repos, cfg, testLogger{t})
+	t.Cleanup(func() { taskRunner.Shutdown(context.Background()) })
+
+	router := gin.New()
+	SetupRoutes(router.Group("/api/v1"), repos, testLogger{t}, cfg, taskRunner, tokens, nil)
+	return &testServer{t: t, router: router, repos: repos, runner: taskRunner}
+}
+
+// do 发送请求，body 不为 nil 时编码为 JSON
+func (s *testServer) do(method, path string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("编码请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// expect 发送请求并检查状态码
+func (s *testServer) expect(status int, method, path string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	s.t.Helper()
+	w := s.do(method, path, body, header)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: 状态码 %d，期望 %d，响应 %s", method, path, w.Code, status, w.Body.String())
+	}
+	return w
+}
+
+// addUser 创建启用的用户
+func (s *testServer) addUser(username, role string) *repository.User {
+	s.t.Helper()
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		s.t.Fatalf("生成密码哈希失败: %v", err)
+	}
+	user := &repository.User{Username: username, PasswordHash: hash, Role: role, Enabled: true}
+	if err := s.repos.Users.Create(user); err != nil {
+		s.t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// login 登录并返回携带访问令牌的请求头
+func (s *testServer) login(username string) http.Header {
+	s.t.Helper()
+	w := s.expect(200, "POST", "/api/v1/auth/login", gin.H{"username": username, "password": testPassword}, nil)
+
+	var resp struct {
+		Tokens struct {
+			AccessToken string `json:"access_token"`
+		} `json:"tokens"`
+	}
+	decodeBody(s.t, w, &resp)
+	return http.Header{"Authorization": {"Bearer " + resp.Tokens.AccessToken}}
+}
+
+// createSite 通过接口创建站点并返回ID
+func (s *testServer) createSite(name string, header http.Header) int {
+	s.t.Helper()
+	w := s.expect(201, "POST", "/api/v1/sites", gin.H{
+		"name":       name,
+		"base_url":   "https://example.com",
+		"start_urls": []string{"https://example.com/"},
+		"selectors":  gin.H{"title": "h1"},
+		"enabled":    true,
+	}, header)
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	decodeBody(s.t, w, &resp)
+	return resp.ID
+}
+
+// addItem 直接写入一条数据
+func (s *testServer) addItem(siteID int, url string) *repository.Item {
+	s.t.Helper()
+	item := &repository.Item{SiteID: siteID, URL: url, Title: "标题", Status: "new"}
+	if _, err := s.repos.Items.Save(item); err != nil {
+		s.t.Fatalf("保存数据失败: %v", err)
+	}
+	return item
+}
+
+// decodeBody 解析 JSON 响应
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("解析响应失败: %v，响应 %s", err, w.Body.String())
+	}
+}
+
+// path 格式化请求路径
+func path(format string, args ...interface{}) string {
+	return "/api/v1" + fmt.Sprintf(format, args...)
+}
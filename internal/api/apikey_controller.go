@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController API密钥管理控制器
+type APIKeyController struct {
+	repos  *repository.Repositories
+	logger utils.Logger
+}
+
+// NewAPIKeyController 创建API密钥管理控制器
+func NewAPIKeyController(repos *repository.Repositories, logger utils.Logger) *APIKeyController {
+	return &APIKeyController{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// CreateAPIKeyRequest 创建API密钥请求结构
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	SiteIDs   []int      `json:"site_ids"`   // 允许访问的站点，为空时不限制
+	ExpiresAt *time.Time `json:"expires_at"` // 过期时间，为空时不过期
+}
+
+// ListAPIKeys 获取API密钥列表，不包含密钥本身
+func (kc *APIKeyController) ListAPIKeys(c *gin.Context) {
+	keys, err := kc.repos.APIKeys.List()
+	if err != nil {
+		kc.logger.Error("查询API密钥列表失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
+	}
+	if keys == nil {
+		keys = []repository.APIKey{}
+	}
+	c.JSON(200, gin.H{
+		"api_keys": keys,
+		"scopes":   auth.Scopes(),
+	})
+}
+
+// CreateAPIKey 创建API密钥，完整密钥只在响应中返回一次
+func (kc *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(400, gin.H{"error": "名称不能为空"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		c.JSON(400, gin.H{"error": "至少需要一个授权范围"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			c.JSON(400, gin.H{"error": "未知的授权范围: " + scope})
+			return
+		}
+	}
+	for _, siteID := range req.SiteIDs {
+		_, err := kc.repos.Sites.Get(siteID)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(400, gin.H{"error": "站点不存在: " + strconv.Itoa(siteID)})
+			return
+		}
+		if err != nil {
+			kc.logger.Error("查询站点失败", "id", siteID, "error", err)
+			c.JSON(500, gin.H{"error": "创建失败"})
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(400, gin.H{"error": "过期时间必须晚于当前时间"})
+		return
+	}
+
+	raw, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		kc.logger.Error("生成API密钥失败", "error", err)
+		c.JSON(500, gin.H{"error": "创建失败"})
+		return
+	}
+
+	key := &repository.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   auth.HashAPIKey(raw),
+		Scopes:    req.Scopes,
+		SiteIDs:   req.SiteIDs,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if user := currentUser(c); user != nil {
+		key.CreatedBy = &user.ID
+	}
+	if err := kc.repos.APIKeys.Create(key); err != nil {
+		kc.logger.Error("创建API密钥失败", "name", req.Name, "error", err)
+		c.JSON(500, gin.H{"error": "创建失败"})
+		return
+	}
+
+	kc.logger.Info("创建API密钥", "id", key.ID, "name", key.Name, "scopes", key.Scopes, "operator", operatorName(c))
+	setAuditTarget(c, key.ID)
+	c.JSON(201, gin.H{
+		"api_key": key,
+		"key":     raw,
+		"message": "请妥善保存密钥，之后将无法再次查看",
+	})
+}
+
+// DeleteAPIKey 删除API密钥，使用该密钥的请求随即失效
+func (kc *APIKeyController) DeleteAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的API密钥ID"})
+		return
+	}
+
+	err = kc.repos.APIKeys.Delete(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "API密钥不存在"})
+		return
+	}
+	if err != nil {
+		kc.logger.Error("删除API密钥失败", "id", id, "error", err)
+		c.JSON(500, gin.H{"error": "删除失败"})
+		return
+	}
+
+	kc.logger.Info("删除API密钥", "id", id, "operator", operatorName(c))
+	c.JSON(200, gin.H{"message": "API密钥已删除"})
+}
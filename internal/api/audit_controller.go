@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 审计记录的操作者类型
+const (
+	auditActorUser      = "user"
+	auditActorAPIKey    = "api_key"
+	auditActorAnonymous = "anonymous" // 未启用认证
+)
+
+// 处理函数写入请求上下文、供审计中间件读取的键
+const (
+	contextAuditTargetKey  = "audit_target"
+	contextAuditDetailsKey = "audit_details"
+)
+
+// auditIgnoredFields 比较前后状态时忽略的字段，每次修改都会变化
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditTarget 审计对象类型及读取其当前状态的方法。
+// id 为空时从路由参数 id 读取，创建类操作由处理函数通过 setAuditTarget 指定
+type auditTarget struct {
+	kind string
+	id   string
+	load func(id string) (interface{}, error) // 对象不存在时返回 nil, nil
+}
+
+// AuditController 操作审计控制器，负责记录修改类操作并提供查询接口
+type AuditController struct {
+	repos  *repository.Repositories
+	logger utils.Logger
+}
+
+// NewAuditController 创建操作审计控制器
+func NewAuditController(repos *repository.Repositories, logger utils.Logger) *AuditController {
+	return &AuditController{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// Record 审计中间件，挂载在修改类接口的处理函数之前。记录处理前后对象状态的差异、
+// 操作者和客户端IP，只记录成功（状态码小于 400）的请求。写入失败不影响请求结果
+func (ac *AuditController) Record(action string, target auditTarget) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := target.id
+		if id == "" {
+			id = c.Param("id")
+		}
+		before := ac.loadState(target, id)
+
+		c.Next()
+
+		if c.Writer.Status() >= 400 {
+			return
+		}
+		if value := c.GetString(contextAuditTargetKey); value != "" {
+			id = value
+		}
+
+		entry := &repository.AuditEntry{
+			Action:     action,
+			TargetType: target.kind,
+			TargetID:   id,
+			Changes:    auditDiff(before, ac.loadState(target, id)),
+			IP:         c.ClientIP(),
+		}
+		if details, ok := c.Get(contextAuditDetailsKey); ok {
+			entry.Details, _ = details.(map[string]interface{})
+		}
+		setAuditActor(c, entry)
+
+		if err := ac.repos.Audit.Create(entry); err != nil {
+			ac.logger.Error("写入审计记录失败", "action", action, "target", id, "error", err)
+		}
+	}
+}
+
+// loadState 读取对象当前状态，读取失败时记录日志并视为空
+func (ac *AuditController) loadState(target auditTarget, id string) interface{} {
+	if target.load == nil || id == "" {
+		return nil
+	}
+	state, err := target.load(id)
+	if err != nil {
+		ac.logger.Error("读取审计对象失败", "type", target.kind, "id", id, "error", err)
+		return nil
+	}
+	return state
+}
+
+// ListAuditLog 查询审计记录，支持按操作者、操作、对象和时间过滤
+func (ac *AuditController) ListAuditLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	// 限制每页最大数量
+	if pageSize > 200 {
+		pageSize = 200
+	}
+
+	entries, total, err := ac.repos.Audit.List(repository.AuditFilter{
+		ActorType:  c.Query("actor_type"),
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		StartDate:  c.Query("start_date"),
+		EndDate:    c.Query("end_date"),
+		Pagination: repository.Pagination{Page: page, PageSize: pageSize},
+	})
+	if err != nil {
+		ac.logger.Error("查询审计记录失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
+	}
+	if entries == nil {
+		entries = []repository.AuditEntry{}
+	}
+
+	c.JSON(200, gin.H{
+		"data": entries,
+		"pagination": gin.H{
+			"page":       page,
+			"page_size":  pageSize,
+			"total":      total,
+			"total_page": (total + pageSize - 1) / pageSize,
+		},
+	})
+}
+
+// 审计对象
+func (ac *AuditController) siteTarget() auditTarget {
+	return auditByID("site", func(id int) (interface{}, error) { return ac.repos.Sites.Get(id) })
+}
+
+func (ac *AuditController) scheduleTarget() auditTarget {
+	return auditByID("site", func(id int) (interface{}, error) { return ac.repos.Sites.GetSchedule(id) })
+}
+
+func (ac *AuditController) taskTarget() auditTarget {
+	return auditByID("task", func(id int) (interface{}, error) { return ac.repos.Tasks.Get(id) })
+}
+
+func (ac *AuditController) userTarget() auditTarget {
+	return auditByID("user", func(id int) (interface{}, error) { return ac.repos.Users.Get(id) })
+}
+
+func (ac *AuditController) apiKeyTarget() auditTarget {
+	return auditByID("api_key", func(id int) (interface{}, error) { return ac.repos.APIKeys.Get(id) })
+}
+
+func (ac *AuditController) configTarget() auditTarget {
+	return auditTarget{kind: "config", id: "system_config", load: func(key string) (interface{}, error) {
+		value, err := ac.repos.Config.Get(key)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var state map[string]interface{}
+		if json.Unmarshal([]byte(value), &state) != nil {
+			return value, nil
+		}
+		return state, nil
+	}}
+}
+
+// backupTarget 备份没有可比较的状态，只记录备份名称
+func (ac *AuditController) backupTarget() auditTarget {
+	return auditTarget{kind: "backup"}
+}
+
+// auditByID 按整数ID读取状态的审计对象。get 返回 ErrNotFound 时视为对象不存在
+func auditByID(kind string, get func(id int) (interface{}, error)) auditTarget {
+	return auditTarget{kind: kind, load: func(value string) (interface{}, error) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, nil
+		}
+		state, err := get(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return state, nil
+	}}
+}
+
+// setAuditTarget 指定审计对象ID，用于创建类操作
+func setAuditTarget(c *gin.Context, id interface{}) {
+	switch v := id.(type) {
+	case int:
+		c.Set(contextAuditTargetKey, strconv.Itoa(v))
+	case string:
+		c.Set(contextAuditTargetKey, v)
+	}
+}
+
+// auditDetail 为本次操作的审计记录添加附加信息
+func auditDetail(c *gin.Context, key string, value interface{}) {
+	details, _ := c.Get(contextAuditDetailsKey)
+	m, ok := details.(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+		c.Set(contextAuditDetailsKey, m)
+	}
+	m[key] = value
+}
+
+// setAuditActor 按认证方式填写操作者
+func setAuditActor(c *gin.Context, entry *repository.AuditEntry) {
+	if user := currentUser(c); user != nil {
+		entry.ActorType = auditActorUser
+		entry.ActorID = &user.ID
+		entry.ActorName = user.Username
+		return
+	}
+	if key := currentAPIKey(c); key != nil {
+		entry.ActorType = auditActorAPIKey
+		entry.ActorID = &key.ID
+		entry.ActorName = key.Name
+		return
+	}
+	entry.ActorType = auditActorAnonymous
+}
+
+// auditDiff 比较前后状态的顶层字段，返回发生变化的字段
+func auditDiff(before, after interface{}) map[string]repository.AuditChange {
+	oldFields, newFields := auditFields(before), auditFields(after)
+	changes := map[string]repository.AuditChange{}
+	for key, value := range oldFields {
+		if !auditIgnoredFields[key] && !reflect.DeepEqual(value, newFields[key]) {
+			changes[key] = repository.AuditChange{Before: value, After: newFields[key]}
+		}
+	}
+	for key, value := range newFields {
+		if _, ok := oldFields[key]; !ok && value != nil && !auditIgnoredFields[key] {
+			changes[key] = repository.AuditChange{After: value}
+		}
+	}
+	return changes
+}
+
+// auditFields 将状态按 JSON 展开为字段，非对象的状态记为 value 字段
+func auditFields(state interface{}) map[string]interface{} {
+	if state == nil {
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal(data, &fields) == nil {
+		return fields
+	}
+	var value interface{}
+	json.Unmarshal(data, &value)
+	return map[string]interface{}{"value": value}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditRecordsChanges(t *testing.T) {
+	s := newTestServer(t, true)
+	admin := s.addUser("admin", auth.RoleAdmin)
+	header := s.login("admin")
+
+	id := s.createSite("站点", header)
+	s.expect(200, "PUT", path("/sites/%d/toggle", id), nil, header)
+	s.expect(404, "PUT", "/api/v1/sites/42/toggle", nil, header) // 失败的请求不记录
+	s.expect(200, "PUT", "/api/v1/system/config", gin.H{"a": 1}, header)
+	s.expect(200, "PUT", "/api/v1/system/config", gin.H{"a": 2}, header)
+
+	var resp struct {
+		Data []repository.AuditEntry `json:"data"`
+	}
+	decodeBody(t, s.expect(200, "GET", "/api/v1/system/audit", nil, header), &resp)
+	if len(resp.Data) != 4 {
+		t.Fatalf("期望 4 条审计记录，实际 %d: %+v", len(resp.Data), resp.Data)
+	}
+
+	toggle := resp.Data[2]
+	if toggle.Action != "site.toggle" || toggle.TargetType != "site" || toggle.TargetID != strconv.Itoa(id) {
+		t.Fatalf("审计记录的操作或对象不符: %+v", toggle)
+	}
+	if toggle.ActorType != auditActorUser || toggle.ActorID == nil || *toggle.ActorID != admin.ID || toggle.ActorName != "admin" {
+		t.Fatalf("审计记录的操作者不符: %+v", toggle)
+	}
+	if change, ok := toggle.Changes["enabled"]; !ok || change.Before != true || change.After != false || len(toggle.Changes) != 1 {
+		t.Fatalf("审计记录的变化不符: %+v", toggle.Changes)
+	}
+
+	update := resp.Data[0]
+	if change := update.Changes["a"]; update.Action != "config.update" || change.Before != float64(1) || change.After != float64(2) {
+		t.Fatalf("配置修改的变化不符: %+v", update)
+	}
+
+	decodeBody(t, s.expect(200, "GET", "/api/v1/system/audit?action=site.create&target_type=site", nil, header), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Changes["name"].After != "站点" {
+		t.Fatalf("按操作过滤的审计记录不符: %+v", resp.Data)
+	}
+
+	s.addUser("viewer", auth.RoleViewer)
+	s.expect(403, "GET", "/api/v1/system/audit", nil, s.login("viewer"))
+}
+
+func TestAuditAnonymousActor(t *testing.T) {
+	s := newTestServer(t, false)
+	s.expect(200, "PUT", "/api/v1/system/config", gin.H{"a": 1}, nil)
+
+	entries, total, err := s.repos.Audit.List(repository.AuditFilter{ActorType: auditActorAnonymous})
+	if err != nil || total != 1 || entries[0].Action != "config.update" {
+		t.Fatalf("未启用认证时应记录匿名操作者: %+v, %v", entries, err)
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthController 登录认证控制器
+type AuthController struct {
+	repos  *repository.Repositories
+	logger utils.Logger
+	tokens *auth.Manager
+}
+
+// NewAuthController 创建登录认证控制器
+func NewAuthController(repos *repository.Repositories, logger utils.Logger, tokens *auth.Manager) *AuthController {
+	return &AuthController{
+		repos:  repos,
+		logger: logger,
+		tokens: tokens,
+	}
+}
+
+// LoginRequest 登录请求结构
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest 刷新令牌请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Login 校验用户名和密码，创建登录会话并签发令牌
+func (ac *AuthController) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ac.repos.Users.GetByUsername(req.Username)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		ac.logger.Error("查询用户失败", "username", req.Username, "error", err)
+		c.JSON(500, gin.H{"error": "登录失败"})
+		return
+	}
+	// 用户不存在和密码错误返回相同的提示
+	if user == nil || !auth.CheckPassword(user.PasswordHash, req.Password) {
+		ac.logger.Warn("登录失败", "username", req.Username, "ip", c.ClientIP())
+		c.JSON(401, gin.H{"error": "用户名或密码错误"})
+		return
+	}
+	if !user.Enabled {
+		c.JSON(403, gin.H{"error": "用户已被禁用"})
+		return
+	}
+
+	sessionID, err := auth.NewSessionID()
+	if err != nil {
+		ac.logger.Error("创建登录会话失败", "error", err)
+		c.JSON(500, gin.H{"error": "登录失败"})
+		return
+	}
+	now := time.Now()
+	session := &repository.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(ac.tokens.RefreshTTL()),
+		CreatedAt: now,
+	}
+	if err := ac.repos.Sessions.Create(session); err != nil {
+		ac.logger.Error("保存登录会话失败", "error", err)
+		c.JSON(500, gin.H{"error": "登录失败"})
+		return
+	}
+
+	tokens, err := ac.tokens.Issue(user.ID, user.Username, session.ID, session.ExpiresAt)
+	if err != nil {
+		ac.logger.Error("签发令牌失败", "error", err)
+		c.JSON(500, gin.H{"error": "登录失败"})
+		return
+	}
+
+	if err := ac.repos.Users.TouchLogin(user.ID, now); err == nil {
+		user.LastLoginAt = &now
+	}
+	// 顺带清理过期会话
+	ac.repos.Sessions.DeleteExpired(now)
+
+	ac.logger.Info("用户登录", "username", user.Username, "ip", c.ClientIP())
+	c.JSON(200, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的令牌，会话已注销或过期时失败
+func (ac *AuthController) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := ac.tokens.Parse(req.RefreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, session, status, message := ac.authenticate(claims)
+	if status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	tokens, err := ac.tokens.Issue(user.ID, user.Username, session.ID, session.ExpiresAt)
+	if err != nil {
+		ac.logger.Error("签发令牌失败", "error", err)
+		c.JSON(500, gin.H{"error": "刷新令牌失败"})
+		return
+	}
+
+	c.JSON(200, gin.H{"tokens": tokens})
+}
+
+// Logout 注销当前登录会话，会话下的访问令牌和刷新令牌随即失效
+func (ac *AuthController) Logout(c *gin.Context) {
+	sessionID := c.GetString(contextSessionKey)
+	if sessionID == "" {
+		c.JSON(400, gin.H{"error": "当前请求没有登录会话"})
+		return
+	}
+	err := ac.repos.Sessions.Revoke(sessionID, time.Now())
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		ac.logger.Error("注销登录会话失败", "error", err)
+		c.JSON(500, gin.H{"error": "注销失败"})
+		return
+	}
+
+	if user := currentUser(c); user != nil {
+		ac.logger.Info("用户注销", "username", user.Username)
+	}
+	c.JSON(200, gin.H{"message": "已注销"})
+}
+
+// Me 返回当前登录的用户及其权限，使用API密钥时返回密钥及其权限
+func (ac *AuthController) Me(c *gin.Context) {
+	user := currentUser(c)
+	if user == nil {
+		key := currentAPIKey(c)
+		c.JSON(200, gin.H{
+			"api_key":     key,
+			"permissions": auth.PermissionsOfScopes(key.Scopes),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"user":        user,
+		"permissions": auth.PermissionsOf(user.Role),
+	})
+}
+
+// ListRoles 返回内置角色及其权限
+func (ac *AuthController) ListRoles(c *gin.Context) {
+	c.JSON(200, gin.H{"roles": auth.Roles()})
+}
+
+// apiKeyTouchInterval 记录API密钥最后使用时间的最小间隔，避免每个请求都写数据库
+const apiKeyTouchInterval = time.Minute
+
+// authenticateAPIKey 校验API密钥是否存在且未过期，并记录最后使用时间，无效时返回状态码和提示
+func (ac *AuthController) authenticateAPIKey(key, ip string) (*repository.APIKey, int, string) {
+	apiKey, err := ac.repos.APIKeys.GetByHash(auth.HashAPIKey(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		ac.logger.Warn("无效的API密钥", "ip", ip)
+		return nil, 401, "无效的API密钥"
+	}
+	if err != nil {
+		ac.logger.Error("查询API密钥失败", "error", err)
+		return nil, 500, "认证失败"
+	}
+
+	now := time.Now()
+	if apiKey.Expired(now) {
+		return nil, 401, "API密钥已过期"
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval || apiKey.LastUsedIP != ip {
+		if err := ac.repos.APIKeys.TouchUsed(apiKey.ID, now, ip); err != nil {
+			ac.logger.Error("记录API密钥使用时间失败", "id", apiKey.ID, "error", err)
+		} else {
+			apiKey.LastUsedAt = &now
+			apiKey.LastUsedIP = ip
+		}
+	}
+	return apiKey, 0, ""
+}
+
+// authenticate 检查令牌对应的会话和用户是否仍然有效，无效时返回状态码和提示
+func (ac *AuthController) authenticate(claims *auth.Claims) (*repository.User, *repository.Session, int, string) {
+	session, err := ac.repos.Sessions.Get(claims.SessionID())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, 401, "会话不存在"
+	}
+	if err != nil {
+		ac.logger.Error("查询登录会话失败", "error", err)
+		return nil, nil, 500, "认证失败"
+	}
+	if !session.Active(time.Now()) || session.UserID != claims.UserID {
+		return nil, nil, 401, "会话已失效，请重新登录"
+	}
+
+	user, err := ac.repos.Users.Get(session.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, 401, "用户不存在"
+	}
+	if err != nil {
+		ac.logger.Error("查询用户失败", "error", err)
+		return nil, nil, 500, "认证失败"
+	}
+	if !user.Enabled {
+		return nil, nil, 403, "用户已被禁用"
+	}
+	return user, session, 0, ""
+}
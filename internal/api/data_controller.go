@@ -1,152 +1,54 @@
 package api
 
 import (
-	"database/sql"
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"example.com/m/v2/internal/repository"
 	"example.com/m/v2/internal/utils"
 )
 
 // DataController 数据控制器
 type DataController struct {
-	db     *sql.DB
+	repos  *repository.Repositories
 	logger utils.Logger
 }
 
 // NewDataController 创建数据控制器
-func NewDataController(db *sql.DB, logger utils.Logger) *DataController {
+func NewDataController(repos *repository.Repositories, logger utils.Logger) *DataController {
 	return &DataController{
-		db:     db,
+		repos:  repos,
 		logger: logger,
 	}
 }
 
 // ItemResponse 数据项响应结构
-type ItemResponse struct {
-	ID           int                    `json:"id"`
-	TaskID       *int                   `json:"task_id"`
-	SiteID       int                    `json:"site_id"`
-	SiteName     string                 `json:"site_name"`
-	URL          string                 `json:"url"`
-	Title        string                 `json:"title"`
-	Content      string                 `json:"content"`
-	Description  string                 `json:"description"`
-	Author       string                 `json:"author"`
-	Source       string                 `json:"source"`
-	Language     string                 `json:"language"`
-	PublishDate  *time.Time             `json:"publish_date"`
-	Keywords     []string               `json:"keywords"`
-	Tags         []string               `json:"tags"`
-	Links        []string               `json:"links"`
-	Images       []string               `json:"images"`
-	Videos       []string               `json:"videos"`
-	Metadata     map[string]interface{} `json:"metadata"`
-	ViewCount    int                    `json:"view_count"`
-	CommentCount int                    `json:"comment_count"`
-	LikeCount    int                    `json:"like_count"`
-	ShareCount   int                    `json:"share_count"`
-	Status       string                 `json:"status"`
-	CrawlTime    time.Time              `json:"crawl_time"`
-}
+type ItemResponse = repository.Item
 
 // ListItems 获取数据项列表
 func (dc *DataController) ListItems(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	siteID := c.Query("site_id")
-	status := c.Query("status")
-	keyword := c.Query("keyword")
-
-	offset := (page - 1) * pageSize
-
-	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
 
-	if siteID != "" {
-		where += " AND cd.site_id = ?"
-		args = append(args, siteID)
-	}
-	if status != "" {
-		where += " AND cd.status = ?"
-		args = append(args, status)
-	}
-	if keyword != "" {
-		where += " AND (cd.title LIKE ? OR cd.content LIKE ?)"
-		args = append(args, "%"+keyword+"%", "%"+keyword+"%")
-	}
-
-	// 查询总数
-	var total int
-	countQuery := `SELECT COUNT(*) FROM crawl_data cd WHERE ` + where
-	err := dc.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		dc.logger.Error("查询数据总数失败", "error", err)
-		c.JSON(500, gin.H{"error": "查询失败"})
+	filter := itemFilter(c)
+	var ok bool
+	if filter.SiteIDs, ok = limitSites(c, filter.SiteID); !ok {
 		return
 	}
-
-	// 查询数据列表
-	query := `
-		SELECT cd.id, cd.task_id, cd.site_id, s.name as site_name, cd.url, cd.title, 
-		       cd.description, cd.author, cd.source, cd.language, cd.publish_date,
-		       cd.keywords, cd.tags, cd.view_count, cd.comment_count, cd.like_count, 
-		       cd.share_count, cd.status, cd.crawl_time
-		FROM crawl_data cd
-		LEFT JOIN sites s ON cd.site_id = s.id
-		WHERE ` + where + `
-		ORDER BY cd.crawl_time DESC 
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, pageSize, offset)
-
-	rows, err := dc.db.Query(query, args...)
+	filter.Pagination = repository.Pagination{Page: page, PageSize: pageSize}
+	items, total, err := dc.repos.Items.List(filter)
 	if err != nil {
 		dc.logger.Error("查询数据列表失败", "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-	defer rows.Close()
-
-	var items []ItemResponse
-	for rows.Next() {
-		var item ItemResponse
-		var taskID sql.NullInt64
-		var publishDate sql.NullTime
-		var keywordsJSON, tagsJSON string
-
-		err := rows.Scan(
-			&item.ID, &taskID, &item.SiteID, &item.SiteName, &item.URL, &item.Title,
-			&item.Description, &item.Author, &item.Source, &item.Language, &publishDate,
-			&keywordsJSON, &tagsJSON, &item.ViewCount, &item.CommentCount, &item.LikeCount,
-			&item.ShareCount, &item.Status, &item.CrawlTime,
-		)
-		if err != nil {
-			dc.logger.Error("扫描数据失败", "error", err)
-			continue
-		}
-
-		if taskID.Valid {
-			id := int(taskID.Int64)
-			item.TaskID = &id
-		}
-		if publishDate.Valid {
-			item.PublishDate = &publishDate.Time
-		}
-
-		// 解析JSON字段
-		if keywordsJSON != "" && keywordsJSON != "null" {
-			json.Unmarshal([]byte(keywordsJSON), &item.Keywords)
-		}
-		if tagsJSON != "" && tagsJSON != "null" {
-			json.Unmarshal([]byte(tagsJSON), &item.Tags)
-		}
-
-		items = append(items, item)
-	}
 
 	c.JSON(200, gin.H{
 		"data": items,
@@ -167,30 +69,8 @@ func (dc *DataController) GetItem(c *gin.Context) {
 		return
 	}
 
-	query := `
-		SELECT cd.id, cd.task_id, cd.site_id, s.name as site_name, cd.url, cd.title, 
-		       cd.content, cd.description, cd.author, cd.source, cd.language, cd.publish_date,
-		       cd.keywords, cd.tags, cd.links, cd.images, cd.videos, cd.metadata,
-		       cd.view_count, cd.comment_count, cd.like_count, cd.share_count, 
-		       cd.status, cd.crawl_time
-		FROM crawl_data cd
-		LEFT JOIN sites s ON cd.site_id = s.id
-		WHERE cd.id = ?
-	`
-
-	var item ItemResponse
-	var taskID sql.NullInt64
-	var publishDate sql.NullTime
-	var keywordsJSON, tagsJSON, linksJSON, imagesJSON, videosJSON, metadataJSON string
-
-	err = dc.db.QueryRow(query, id).Scan(
-		&item.ID, &taskID, &item.SiteID, &item.SiteName, &item.URL, &item.Title,
-		&item.Content, &item.Description, &item.Author, &item.Source, &item.Language, &publishDate,
-		&keywordsJSON, &tagsJSON, &linksJSON, &imagesJSON, &videosJSON, &metadataJSON,
-		&item.ViewCount, &item.CommentCount, &item.LikeCount, &item.ShareCount,
-		&item.Status, &item.CrawlTime,
-	)
-	if err == sql.ErrNoRows {
+	item, err := dc.repos.Items.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(404, gin.H{"error": "数据不存在"})
 		return
 	}
@@ -199,33 +79,8 @@ func (dc *DataController) GetItem(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-
-	if taskID.Valid {
-		id := int(taskID.Int64)
-		item.TaskID = &id
-	}
-	if publishDate.Valid {
-		item.PublishDate = &publishDate.Time
-	}
-
-	// 解析JSON字段
-	if keywordsJSON != "" && keywordsJSON != "null" {
-		json.Unmarshal([]byte(keywordsJSON), &item.Keywords)
-	}
-	if tagsJSON != "" && tagsJSON != "null" {
-		json.Unmarshal([]byte(tagsJSON), &item.Tags)
-	}
-	if linksJSON != "" && linksJSON != "null" {
-		json.Unmarshal([]byte(linksJSON), &item.Links)
-	}
-	if imagesJSON != "" && imagesJSON != "null" {
-		json.Unmarshal([]byte(imagesJSON), &item.Images)
-	}
-	if videosJSON != "" && videosJSON != "null" {
-		json.Unmarshal([]byte(videosJSON), &item.Videos)
-	}
-	if metadataJSON != "" && metadataJSON != "null" {
-		json.Unmarshal([]byte(metadataJSON), &item.Metadata)
+	if !siteAllowed(c, item.SiteID) {
+		return
 	}
 
 	c.JSON(200, gin.H{"data": item})
@@ -240,19 +95,17 @@ func (dc *DataController) DeleteItem(c *gin.Context) {
 	}
 
 	// 删除数据
-	result, err := dc.db.Exec("DELETE FROM crawl_data WHERE id = ?", id)
+	err = dc.repos.Items.Delete(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "数据不存在"})
+		return
+	}
 	if err != nil {
 		dc.logger.Error("删除数据失败", "error", err)
 		c.JSON(500, gin.H{"error": "删除失败"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(404, gin.H{"error": "数据不存在"})
-		return
-	}
-
 	dc.logger.Info("删除数据成功", "id", id)
 	c.JSON(200, gin.H{"message": "数据删除成功"})
 }
@@ -279,81 +132,37 @@ func (dc *DataController) SearchItems(c *gin.Context) {
 	if req.PageSize <= 0 {
 		req.PageSize = 10
 	}
-
-	offset := (req.Page - 1) * req.PageSize
-
-	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
-
-	if req.Keyword != "" {
-		where += " AND MATCH(cd.title, cd.content) AGAINST(? IN BOOLEAN MODE)"
-		args = append(args, req.Keyword)
-	}
-	if req.SiteID > 0 {
-		where += " AND cd.site_id = ?"
-		args = append(args, req.SiteID)
-	}
-	if req.StartDate != "" {
-		where += " AND cd.crawl_time >= ?"
-		args = append(args, req.StartDate)
-	}
-	if req.EndDate != "" {
-		where += " AND cd.crawl_time <= ?"
-		args = append(args, req.EndDate)
-	}
-
-	// 查询总数
-	var total int
-	countQuery := `SELECT COUNT(*) FROM crawl_data cd WHERE ` + where
-	err := dc.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		dc.logger.Error("搜索数据总数失败", "error", err)
-		c.JSON(500, gin.H{"error": "搜索失败"})
+	siteIDs, ok := limitSites(c, req.SiteID)
+	if !ok {
 		return
 	}
 
-	// 查询数据
-	query := `
-		SELECT cd.id, cd.site_id, s.name as site_name, cd.url, cd.title, 
-		       cd.description, cd.author, cd.source, cd.crawl_time
-		FROM crawl_data cd
-		LEFT JOIN sites s ON cd.site_id = s.id
-		WHERE ` + where + `
-		ORDER BY cd.crawl_time DESC 
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, req.PageSize, offset)
-
-	rows, err := dc.db.Query(query, args...)
+	rows, total, err := dc.repos.Items.List(repository.ItemFilter{
+		Search:     req.Keyword,
+		SiteID:     req.SiteID,
+		SiteIDs:    siteIDs,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		Pagination: repository.Pagination{Page: req.Page, PageSize: req.PageSize},
+	})
 	if err != nil {
 		dc.logger.Error("搜索数据失败", "error", err)
 		c.JSON(500, gin.H{"error": "搜索失败"})
 		return
 	}
-	defer rows.Close()
 
 	var items []map[string]interface{}
-	for rows.Next() {
-		var id, siteID int
-		var siteName, url, title, description, author, source string
-		var crawlTime time.Time
-
-		err := rows.Scan(&id, &siteID, &siteName, &url, &title, &description, &author, &source, &crawlTime)
-		if err != nil {
-			continue
-		}
-
+	for _, item := range rows {
 		items = append(items, map[string]interface{}{
-			"id":          id,
-			"site_id":     siteID,
-			"site_name":   siteName,
-			"url":         url,
-			"title":       title,
-			"description": description,
-			"author":      author,
-			"source":      source,
-			"crawl_time":  crawlTime,
+			"id":          item.ID,
+			"site_id":     item.SiteID,
+			"site_name":   item.SiteName,
+			"url":         item.URL,
+			"title":       item.Title,
+			"description": item.Description,
+			"author":      item.Author,
+			"source":      item.Source,
+			"crawl_time":  item.CrawlTime,
 		})
 	}
 
@@ -371,144 +180,244 @@ func (dc *DataController) SearchItems(c *gin.Context) {
 // ExportItems 导出数据
 func (dc *DataController) ExportItems(c *gin.Context) {
 	format := c.DefaultQuery("format", "json")
-	siteID := c.Query("site_id")
+	filter := itemFilter(c)
+	var ok bool
+	if filter.SiteIDs, ok = limitSites(c, filter.SiteID); !ok {
+		return
+	}
 
-	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
+	switch format {
+	case "ndjson", "jsonl":
+		filter.WithContent = true
+		dc.exportNDJSON(c, filter)
+		return
+	case "csv", "excel", "xlsx":
+		columns, err := parseExportColumns(c.Query("columns"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		// 未导出正文时不查询正文，减少数据量
+		filter.WithContent = hasExportColumn(columns, "content")
 
-	if siteID != "" {
-		where += " AND site_id = ?"
-		args = append(args, siteID)
+		if format == "csv" {
+			dc.exportCSV(c, filter, columns)
+		} else {
+			dc.exportExcel(c, filter, columns)
+		}
+		return
 	}
 
 	// 查询数据
-	query := `
-		SELECT url, title, content, description, author, source, publish_date, crawl_time
-		FROM crawl_data 
-		WHERE ` + where + `
-		ORDER BY crawl_time DESC
-		LIMIT 1000
-	`
-
-	rows, err := dc.db.Query(query, args...)
+	filter.WithContent = true
+	filter.Pagination = repository.Pagination{Page: 1, PageSize: 1000}
+	rows, _, err := dc.repos.Items.List(filter)
 	if err != nil {
 		dc.logger.Error("导出数据查询失败", "error", err)
 		c.JSON(500, gin.H{"error": "导出失败"})
 		return
 	}
-	defer rows.Close()
 
 	var data []map[string]interface{}
-	for rows.Next() {
-		var url, title, content, description, author, source string
-		var publishDate sql.NullTime
-		var crawlTime time.Time
+	for _, row := range rows {
+		item := map[string]interface{}{
+			"url":         row.URL,
+			"title":       row.Title,
+			"content":     row.Content,
+			"description": row.Description,
+			"author":      row.Author,
+			"source":      row.Source,
+			"crawl_time":  row.CrawlTime,
+		}
+
+		if row.PublishDate != nil {
+			item["publish_date"] = *row.PublishDate
+		}
+
+		data = append(data, item)
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", "attachment; filename=crawl_data.json")
+	c.JSON(200, gin.H{"data": data})
+}
 
-		err := rows.Scan(&url, &title, &content, &description, &author, &source, &publishDate, &crawlTime)
+// importMaxLineSize 导入时单行数据的最大长度
+const importMaxLineSize = 16 << 20
+
+// importMaxErrors 导入结果中最多返回的错误条数
+const importMaxErrors = 100
+
+// ImportItems 导入 NDJSON 格式的数据，每行一个 ItemResponse，同一站点的相同URL更新已有记录。
+// 站点优先按 site_name 匹配，其次按 site_id，以便导入其他实例导出的数据
+func (dc *DataController) ImportItems(c *gin.Context) {
+	var inserted, updated, rejected int
+	errs := []gin.H{}
+	reject := func(line int, reason string) {
+		rejected++
+		if len(errs) < importMaxErrors {
+			errs = append(errs, gin.H{"line": line, "error": reason})
+		}
+	}
+
+	// 大文件上传耗时可能超过服务器读写超时，超时后已读取的行已经保存，
+	// 剩余部分被中断，因此在读取请求体前取消读写超时
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		dc.logger.Warn("取消读超时失败", "error", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		dc.logger.Warn("取消写超时失败", "error", err)
+	}
+
+	siteIDs := map[string]int{}
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64*1024), importMaxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var item ItemResponse
+		if err := json.Unmarshal(data, &item); err != nil {
+			reject(line, "JSON格式错误: "+err.Error())
+			continue
+		}
+		if item.URL == "" {
+			reject(line, "缺少url")
+			continue
+		}
+
+		siteID, err := dc.importSiteID(&item, siteIDs)
 		if err != nil {
+			reject(line, err.Error())
 			continue
 		}
 
-		item := map[string]interface{}{
-			"url":         url,
-			"title":       title,
-			"content":     content,
-			"description": description,
-			"author":      author,
-			"source":      source,
-			"crawl_time":  crawlTime,
+		// 任务属于导出方实例，导入后不再关联
+		item.ID = 0
+		item.SiteID = siteID
+		item.TaskID = nil
+
+		created, err := dc.repos.Items.Save(&item)
+		if err != nil {
+			dc.logger.Error("导入数据失败", "line", line, "url", item.URL, "error", err)
+			reject(line, "保存失败")
+			continue
 		}
+		if created {
+			inserted++
+		} else {
+			updated++
+		}
+	}
 
-		if publishDate.Valid {
-			item["publish_date"] = publishDate.Time
+	summary := gin.H{
+		"inserted": inserted,
+		"updated":  updated,
+		"rejected": rejected,
+		"errors":   errs,
+	}
+	if err := scanner.Err(); err != nil {
+		dc.logger.Error("读取导入数据失败", "line", line+1, "error", err)
+		if errors.Is(err, bufio.ErrTooLong) {
+			summary["error"] = fmt.Sprintf("第%d行超过最大长度 %dMB", line+1, importMaxLineSize>>20)
+		} else {
+			summary["error"] = fmt.Sprintf("第%d行读取失败: %v", line+1, err)
 		}
+		c.JSON(400, summary)
+		return
+	}
 
-		data = append(data, item)
+	dc.logger.Info("导入数据完成", "inserted", inserted, "updated", updated, "rejected", rejected)
+	summary["message"] = "导入完成"
+	c.JSON(200, summary)
+}
+
+// importSiteID 解析导入数据所属的站点，结果缓存在 cache 中
+func (dc *DataController) importSiteID(item *ItemResponse, cache map[string]int) (int, error) {
+	key := "id:" + strconv.Itoa(item.SiteID)
+	if item.SiteName != "" {
+		key = "name:" + item.SiteName
+	}
+	if id, ok := cache[key]; ok {
+		if id == 0 {
+			return 0, fmt.Errorf("站点不存在")
+		}
+		return id, nil
 	}
 
-	// 根据格式返回数据
-	switch format {
-	case "csv":
-		c.Header("Content-Type", "text/csv")
-		c.Header("Content-Disposition", "attachment; filename=crawl_data.csv")
-		// TODO: 实现CSV格式导出
-		c.JSON(200, gin.H{"message": "CSV导出功能开发中"})
-	case "excel":
-		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-		c.Header("Content-Disposition", "attachment; filename=crawl_data.xlsx")
-		// TODO: 实现Excel格式导出
-		c.JSON(200, gin.H{"message": "Excel导出功能开发中"})
-	default:
-		c.Header("Content-Type", "application/json")
-		c.Header("Content-Disposition", "attachment; filename=crawl_data.json")
-		c.JSON(200, gin.H{"data": data})
+	var site *repository.Site
+	var err error
+	if item.SiteName != "" {
+		site, err = dc.repos.Sites.GetByName(item.SiteName)
+	}
+	if item.SiteName == "" || errors.Is(err, repository.ErrNotFound) {
+		site, err = dc.repos.Sites.Get(item.SiteID)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		cache[key] = 0
+		return 0, fmt.Errorf("站点不存在")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	cache[key] = site.ID
+	return site.ID, nil
+}
+
+// itemFilter 解析数据列表和导出共用的查询条件
+func itemFilter(c *gin.Context) repository.ItemFilter {
+	siteID, _ := strconv.Atoi(c.Query("site_id"))
+	return repository.ItemFilter{
+		SiteID:       siteID,
+		Status:       c.Query("status"),
+		ChangeStatus: c.Query("change_status"),
+		Keyword:      c.Query("keyword"),
+		StartDate:    c.Query("start_date"),
+		EndDate:      c.Query("end_date"),
 	}
 }
 
 // GetStatistics 获取统计信息
 func (dc *DataController) GetStatistics(c *gin.Context) {
-	// 总数统计
-	var totalItems, totalSites, totalTasks int
-	dc.db.QueryRow("SELECT COUNT(*) FROM crawl_data").Scan(&totalItems)
-	dc.db.QueryRow("SELECT COUNT(*) FROM sites").Scan(&totalSites)
-	dc.db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&totalTasks)
-
-	// 今日数据
-	var todayItems int
-	dc.db.QueryRow("SELECT COUNT(*) FROM crawl_data WHERE DATE(crawl_time) = CURDATE()").Scan(&todayItems)
-
-	// 按站点统计
-	siteStatsQuery := `
-		SELECT s.name, COUNT(cd.id) as count
-		FROM sites s
-		LEFT JOIN crawl_data cd ON s.id = cd.site_id
-		GROUP BY s.id, s.name
-		ORDER BY count DESC
-		LIMIT 10
-	`
-	siteRows, _ := dc.db.Query(siteStatsQuery)
-	defer siteRows.Close()
-
-	var siteStats []map[string]interface{}
-	for siteRows.Next() {
-		var siteName string
-		var count int
-		siteRows.Scan(&siteName, &count)
-		siteStats = append(siteStats, map[string]interface{}{
-			"name":  siteName,
-			"count": count,
-		})
+	if restrictedSites(c) != nil {
+		c.JSON(403, gin.H{"error": "限定站点的API密钥不能查看全局统计"})
+		return
 	}
 
-	// 最近7天统计
-	recentStatsQuery := `
-		SELECT DATE(crawl_time) as date, COUNT(*) as count
-		FROM crawl_data 
-		WHERE crawl_time >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
-		GROUP BY DATE(crawl_time)
-		ORDER BY date DESC
-	`
-	recentRows, _ := dc.db.Query(recentStatsQuery)
-	defer recentRows.Close()
-
-	var recentStats []map[string]interface{}
-	for recentRows.Next() {
-		var date string
-		var count int
-		recentRows.Scan(&date, &count)
-		recentStats = append(recentStats, map[string]interface{}{
-			"date":  date,
-			"count": count,
-		})
+	stats, err := dc.repos.Items.Stats()
+	if err != nil {
+		dc.logger.Error("查询数据统计失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
+	}
+
+	totalSites, err := dc.repos.Sites.Count(repository.SiteFilter{})
+	if err != nil {
+		dc.logger.Error("查询站点总数失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
+	}
+
+	totalTasks, err := dc.repos.Tasks.Count(repository.TaskFilter{})
+	if err != nil {
+		dc.logger.Error("查询任务总数失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
 	}
 
 	c.JSON(200, gin.H{
-		"total_items": totalItems,
-		"total_sites": totalSites,
-		"total_tasks": totalTasks,
-		"today_items": todayItems,
-		"site_stats":  siteStats,
-		"recent_stats": recentStats,
+		"total_items":  stats.Total,
+		"total_sites":  totalSites,
+		"total_tasks":  totalTasks,
+		"today_items":  stats.Today,
+		"site_stats":   stats.BySite,
+		"recent_stats": stats.Recent,
 	})
-} 
\ No newline at end of file
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/export"
+	"example.com/m/v2/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportFlushRows 流式导出时每写出多少行刷新一次响应
+const exportFlushRows = 1000
+
+// defaultExportColumns 未指定 columns 参数时导出的字段
+var defaultExportColumns = []string{
+	"url", "title", "content", "description", "author", "source", "publish_date", "crawl_time",
+}
+
+// parseExportColumns 解析逗号分隔的导出字段，为空时使用默认字段
+func parseExportColumns(value string) ([]export.Column, error) {
+	names := defaultExportColumns
+	if strings.TrimSpace(value) != "" {
+		names = strings.Split(value, ",")
+	}
+
+	columns, err := export.Select(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("导出字段不能为空")
+	}
+	return columns, nil
+}
+
+// hasExportColumn 判断导出字段中是否包含指定字段
+func hasExportColumn(columns []export.Column, name string) bool {
+	for _, column := range columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// disableWriteDeadline 取消服务器写超时。导出数据量大时耗时可能超过 WriteTimeout，
+// 超时后连接被中断，客户端却已收到 200 状态码，得到不完整的文件
+func (dc *DataController) disableWriteDeadline(c *gin.Context) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		dc.logger.Warn("取消写超时失败", "error", err)
+	}
+}
+
+// exportCSV 以 CSV 格式逐行写出数据。文件以 UTF-8 BOM 开头，便于 Excel 正确识别中文
+func (dc *DataController) exportCSV(c *gin.Context, filter repository.ItemFilter, columns []export.Column) {
+	dc.disableWriteDeadline(c)
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename=crawl_data.csv")
+	c.Status(200)
+
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(c.Writer)
+
+	w.Write(export.Header(columns))
+
+	count := 0
+	record := make([]string, len(columns))
+	err := dc.repos.Items.Each(filter, func(item *ItemResponse) error {
+		for i, value := range export.Row(columns, item) {
+			record[i] = fmt.Sprint(value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+
+		// 定期刷新，让客户端尽早收到数据
+		count++
+		if count%exportFlushRows == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+	w.Flush()
+
+	// 响应头已发送，出错时只能记录日志并中断输出
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		dc.logger.Error("导出CSV失败", "rows", count, "error", err)
+		c.Abort()
+		return
+	}
+	dc.logger.Info("导出CSV成功", "rows", count)
+}
+
+// exportExcel 以 xlsx 格式导出数据。行数据通过流式写入器写出，超出内存阈值的部分由 excelize 暂存到临时文件
+func (dc *DataController) exportExcel(c *gin.Context, filter repository.ItemFilter, columns []export.Column) {
+	// 写出文件前需先读取全部数据，读取时间同样计入写超时
+	dc.disableWriteDeadline(c)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		dc.logger.Error("创建Excel写入器失败", "error", err)
+		c.JSON(500, gin.H{"error": "导出失败"})
+		return
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, name := range export.Header(columns) {
+		header[i] = name
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		dc.logger.Error("写入Excel表头失败", "error", err)
+		c.JSON(500, gin.H{"error": "导出失败"})
+		return
+	}
+
+	rowNum := 1
+	err = dc.repos.Items.Each(filter, func(item *ItemResponse) error {
+		rowNum++
+		if rowNum > excelize.TotalRows {
+			return fmt.Errorf("数据超过Excel最大行数 %d", excelize.TotalRows)
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+		return sw.SetRow(cell, export.Row(columns, item))
+	})
+	if err == nil {
+		err = sw.Flush()
+	}
+	if err != nil {
+		dc.logger.Error("导出Excel失败", "error", err)
+		c.JSON(500, gin.H{"error": "导出失败", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename=crawl_data.xlsx")
+	c.Status(200)
+	if err := f.Write(c.Writer); err != nil {
+		dc.logger.Error("写出Excel文件失败", "error", err)
+		c.Abort()
+		return
+	}
+	dc.logger.Info("导出Excel成功", "rows", rowNum-1)
+}
+
+// exportNDJSON 以 NDJSON 格式逐行写出数据，每行一个 ItemResponse，响应使用分块传输
+func (dc *DataController) exportNDJSON(c *gin.Context, filter repository.ItemFilter) {
+	dc.disableWriteDeadline(c)
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=crawl_data.ndjson")
+	c.Status(200)
+
+	enc := json.NewEncoder(c.Writer)
+	enc.SetEscapeHTML(false)
+
+	count := 0
+	err := dc.repos.Items.Each(filter, func(item *ItemResponse) error {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushRows == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+
+	// 响应头已发送，出错时只能记录日志并中断输出
+	if err != nil {
+		dc.logger.Error("导出NDJSON失败", "rows", count, "error", err)
+		c.Abort()
+		return
+	}
+	dc.logger.Info("导出NDJSON成功", "rows", count)
+}
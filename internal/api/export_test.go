@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// slowItems 逐条读取时定期暂停，模拟大量数据的导出耗时
+type slowItems struct {
+	repository.ItemRepository
+	every int
+	delay time.Duration
+}
+
+func (s slowItems) Each(filter repository.ItemFilter, fn func(item *repository.Item) error) error {
+	count := 0
+	return s.ItemRepository.Each(filter, func(item *repository.Item) error {
+		count++
+		if count%s.every == 0 {
+			time.Sleep(s.delay)
+		}
+		return fn(item)
+	})
+}
+
+// newExportServer 创建写超时很短的服务，导出总耗时超过写超时
+func newExportServer(t *testing.T, rows int) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := repository.NewMemoryRepositories()
+	site := &repository.Site{Name: "站点", BaseURL: "https://example.com", Enabled: true}
+	if err := repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+	for i := 0; i < rows; i++ {
+		item := &repository.Item{SiteID: site.ID, URL: fmt.Sprintf("https://example.com/%d", i), Title: "标题", Status: "new"}
+		if _, err := repos.Items.Save(item); err != nil {
+			t.Fatalf("保存数据失败: %v", err)
+		}
+	}
+	repos.Items = slowItems{ItemRepository: repos.Items, every: exportFlushRows / 2, delay: 100 * time.Millisecond}
+
+	router := gin.New()
+	router.GET("/export", NewDataController(repos, testLogger{t}).ExportItems)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExportOutlivesWriteTimeout(t *testing.T) {
+	const rows = 2*exportFlushRows + 500
+	srv := newExportServer(t, rows)
+
+	tests := []struct {
+		format string
+		count  func(t *testing.T, body []byte) int
+	}{
+		{"csv", func(t *testing.T, body []byte) int {
+			lines := 0
+			scanner := bufio.NewScanner(bytes.NewReader(body))
+			for scanner.Scan() {
+				lines++
+			}
+			return lines - 1 // 表头
+		}},
+		{"ndjson", func(t *testing.T, body []byte) int {
+			return bytes.Count(body, []byte("\n"))
+		}},
+		{"excel", func(t *testing.T, body []byte) int {
+			f, err := excelize.OpenReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("解析Excel失败: %v", err)
+			}
+			defer f.Close()
+			sheetRows, err := f.GetRows(f.GetSheetName(0))
+			if err != nil {
+				t.Fatalf("读取Excel行失败: %v", err)
+			}
+			return len(sheetRows) - 1
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "/export?format=" + tt.format + "&columns=id,url,title")
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != 200 {
+				t.Fatalf("状态码 %d", resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("读取到 %d 字节后中断: %v", len(body), err)
+			}
+			if got := tt.count(t, body); got != rows {
+				t.Fatalf("导出 %d 行，期望 %d 行", got, rows)
+			}
+		})
+	}
+}
+
+func TestImportOutlivesReadTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := repository.NewMemoryRepositories()
+	site := &repository.Site{Name: "站点", BaseURL: "https://example.com", Enabled: true}
+	if err := repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/import", NewDataController(repos, testLogger{t}).ImportItems)
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.ReadTimeout = 150 * time.Millisecond
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	// 分批缓慢上传，总耗时超过读写超时
+	const batches, perBatch = 5, 100
+	pr, pw := io.Pipe()
+	go func() {
+		for b := 0; b < batches; b++ {
+			for i := 0; i < perBatch; i++ {
+				fmt.Fprintf(pw, "{\"site_id\": %d, \"url\": \"https://example.com/%d/%d\", \"title\": \"标题\"}\n", site.ID, b, i)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		pw.Close()
+	}()
+
+	resp, err := http.Post(srv.URL+"/import", "application/x-ndjson", pr)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		t.Fatalf("状态码 %d，响应 %s", resp.StatusCode, body)
+	}
+	stats, err := repos.Items.Stats()
+	if err != nil {
+		t.Fatalf("统计失败: %v", err)
+	}
+	if stats.Total != batches*perBatch {
+		t.Fatalf("导入 %d 条，期望 %d 条，响应 %s", stats.Total, batches*perBatch, body)
+	}
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := repository.NewMemoryRepositories()
+	site := &repository.Site{Name: "站点", BaseURL: "https://example.com", Enabled: true}
+	if err := repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+	item := &repository.Item{SiteID: site.ID, URL: "https://example.com/a", Title: "=HYPERLINK(\"http://evil\")", Author: "@x"}
+	if _, err := repos.Items.Save(item); err != nil {
+		t.Fatalf("保存数据失败: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/export", NewDataController(repos, testLogger{t}).ExportItems)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	want := []string{"https://example.com/a", "'=HYPERLINK(\"http://evil\")", "'@x"}
+	tests := []struct {
+		format string
+		row    func(t *testing.T, body []byte) []string
+	}{
+		{"csv", func(t *testing.T, body []byte) []string {
+			records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xEF\xBB\xBF")))).ReadAll()
+			if err != nil || len(records) != 2 {
+				t.Fatalf("解析CSV失败: %v，%d 行", err, len(records))
+			}
+			return records[1]
+		}},
+		{"excel", func(t *testing.T, body []byte) []string {
+			f, err := excelize.OpenReader(bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("解析Excel失败: %v", err)
+			}
+			defer f.Close()
+			rows, err := f.GetRows(f.GetSheetName(0))
+			if err != nil || len(rows) != 2 {
+				t.Fatalf("读取Excel行失败: %v，%d 行", err, len(rows))
+			}
+			return rows[1]
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			resp, err := http.Get(srv.URL + "/export?format=" + tt.format + "&columns=url,title,author")
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			row := tt.row(t, body)
+			for i := range want {
+				if i >= len(row) || row[i] != want[i] {
+					t.Fatalf("导出行 = %q，期望 %q", row, want)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,18 @@
 package api
 
 import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/ratelimit"
+	"example.com/m/v2/internal/repository"
 	"example.com/m/v2/internal/utils"
 )
 
@@ -13,8 +21,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", apiKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -37,7 +45,7 @@ func LoggerMiddleware(logger utils.Logger) gin.HandlerFunc {
 
 		// 获取请求信息
 		reqMethod := c.Request.Method
-		reqUri := c.Request.RequestURI
+		reqUri := redactURI(c.Request.RequestURI)
 		statusCode := c.Writer.Status()
 		clientIP := c.ClientIP()
 
@@ -52,22 +60,244 @@ func LoggerMiddleware(logger utils.Logger) gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware 认证中间件（预留）
-func AuthMiddleware() gin.HandlerFunc {
+// RequestLogFormatter gin.Logger 的日志格式，与默认格式相同但隐藏查询参数中的访问令牌
+func RequestLogFormatter(param gin.LogFormatterParams) string {
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		redactURI(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// accessTokenParam 携带访问令牌的查询参数，只有 SSE 路由接受
+const accessTokenParam = "access_token"
+
+// redactURI 隐藏请求地址中的访问令牌，用于写日志。查询参数无法解析时只保留路径
+func redactURI(uri string) string {
+	path, rawQuery, found := strings.Cut(uri, "?")
+	if !found || !strings.Contains(rawQuery, accessTokenParam) {
+		return uri
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path
+	}
+	if query.Has(accessTokenParam) {
+		query.Set(accessTokenParam, "REDACTED")
+	}
+	return path + "?" + query.Encode()
+}
+
+// 认证通过后写入请求上下文的键
+const (
+	contextUserKey    = "user"
+	contextSessionKey = "session_id"
+	contextAPIKeyKey  = "api_key"
+)
+
+// apiKeyHeader 携带API密钥的请求头
+const apiKeyHeader = "X-API-Key"
+
+// AuthMiddleware 认证中间件，校验 Authorization: Bearer 访问令牌及其登录会话，
+// 通过后将当前用户写入请求上下文。SSE 路由（路径以 /stream 结尾）无法设置请求头，可使用 access_token 查询参数。
+// 请求携带 X-API-Key 时改为校验API密钥，并将密钥写入请求上下文
+func AuthMiddleware(ac *AuthController) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现JWT认证逻辑
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			apiKey, status, message := ac.authenticateAPIKey(key, c.ClientIP())
+			if status == 401 {
+				unauthorized(c, message)
+				return
+			}
+			if status != 0 {
+				c.AbortWithStatusJSON(status, gin.H{"error": message})
+				return
+			}
+			c.Set(contextAPIKeyKey, apiKey)
+			c.Next()
+			return
+		}
+
+		token := bearerToken(c)
+		if token == "" {
+			unauthorized(c, "未登录")
+			return
+		}
+
+		claims, err := ac.tokens.Parse(token, auth.TokenTypeAccess)
+		if err != nil {
+			unauthorized(c, err.Error())
+			return
+		}
+
+		user, session, status, message := ac.authenticate(claims)
+		if status == 401 {
+			unauthorized(c, message)
+			return
+		}
+		if status != 0 {
+			c.AbortWithStatusJSON(status, gin.H{"error": message})
+			return
+		}
+
+		c.Set(contextUserKey, user)
+		c.Set(contextSessionKey, session.ID)
 		c.Next()
 	}
 }
 
-// RateLimitMiddleware 限流中间件（预留）
-func RateLimitMiddleware() gin.HandlerFunc {
+// RequirePermission 权限检查中间件，需挂载在 AuthMiddleware 之后。
+// 当前用户的角色或API密钥的授权范围没有指定权限时返回 403，并说明缺少的权限
+func RequirePermission(perm auth.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现限流逻辑
+		if user := currentUser(c); user != nil {
+			if !auth.HasPermission(user.Role, perm) {
+				c.AbortWithStatusJSON(403, gin.H{
+					"error":      "权限不足，需要 " + string(perm) + " 权限",
+					"permission": perm,
+					"role":       user.Role,
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key := currentAPIKey(c)
+		if key == nil {
+			unauthorized(c, "未登录")
+			return
+		}
+		if !auth.ScopesAllow(key.Scopes, perm) {
+			c.AbortWithStatusJSON(403, gin.H{
+				"error":      "API密钥权限不足，需要 " + string(perm) + " 权限",
+				"permission": perm,
+				"scopes":     key.Scopes,
+			})
+			return
+		}
 		c.Next()
 	}
 }
 
+// bearerToken 从请求头读取访问令牌，SSE 路由还可以从 access_token 查询参数读取。
+// 其他路由不接受查询参数，避免令牌出现在访问日志、代理日志和浏览器历史中
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	if strings.HasSuffix(c.FullPath(), "/stream") {
+		return c.Query(accessTokenParam)
+	}
+	return ""
+}
+
+// unauthorized 返回 401 并中断请求
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(401, gin.H{"error": message})
+}
+
+// currentUser 返回认证中间件写入的当前用户，未启用认证时为 nil
+func currentUser(c *gin.Context) *repository.User {
+	if value, ok := c.Get(contextUserKey); ok {
+		if user, ok := value.(*repository.User); ok {
+			return user
+		}
+	}
+	return nil
+}
+
+// currentAPIKey 返回认证中间件写入的API密钥，使用登录令牌或未启用认证时为 nil
+func currentAPIKey(c *gin.Context) *repository.APIKey {
+	if value, ok := c.Get(contextAPIKeyKey); ok {
+		if key, ok := value.(*repository.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// restrictedSites 返回当前API密钥限定的站点，不受限制时为 nil
+func restrictedSites(c *gin.Context) []int {
+	if key := currentAPIKey(c); key != nil && len(key.SiteIDs) > 0 {
+		return key.SiteIDs
+	}
+	return nil
+}
+
+// siteAllowed 检查当前请求能否访问指定站点，不能访问时返回 403 并返回 false。
+// 只有限定了站点的API密钥会被拒绝
+func siteAllowed(c *gin.Context, siteID int) bool {
+	if key := currentAPIKey(c); key != nil && !key.AllowsSite(siteID) {
+		c.JSON(403, gin.H{"error": "API密钥无权访问该站点", "site_id": siteID})
+		return false
+	}
+	return true
+}
+
+// limitSites 计算列表查询的站点范围：指定了站点时检查能否访问，
+// 否则返回API密钥限定的站点作为查询条件
+func limitSites(c *gin.Context, siteID int) ([]int, bool) {
+	if siteID > 0 {
+		return nil, siteAllowed(c, siteID)
+	}
+	return restrictedSites(c), true
+}
+
+// RateLimitMiddleware 令牌桶限流中间件，按API密钥、登录用户或客户端IP分别计数，
+// 需挂载在 AuthMiddleware 之后才能区分API密钥和用户。name 区分不同的限额，
+// 同一客户端在不同限额下的令牌互不影响。响应带有 X-RateLimit-Limit、X-RateLimit-Remaining
+// 和 X-RateLimit-Reset（恢复满额的秒数），超出限额时返回 429 及 Retry-After
+func RateLimitMiddleware(store ratelimit.Store, name string, limit ratelimit.Limit, logger utils.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := rateLimitClient(c)
+		result, err := store.Take(name+":"+client, limit, time.Now())
+		if err != nil {
+			// 限流存储不可用时放行，避免影响正常访问
+			logger.Error("限流检查失败", "name", name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			logger.Warn("请求被限流", "name", name, "client", client, "uri", redactURI(c.Request.RequestURI))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(429, gin.H{
+				"error":       "请求过于频繁，请稍后再试",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitClient 限流的客户端标识，依次使用API密钥、登录用户和客户端IP
+func rateLimitClient(c *gin.Context) string {
+	if key := currentAPIKey(c); key != nil {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+	if user := currentUser(c); user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds 将时间间隔向上取整为秒
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
 // ErrorHandlerMiddleware 错误处理中间件
 func ErrorHandlerMiddleware() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
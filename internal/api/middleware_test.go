@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/ratelimit"
+	"example.com/m/v2/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRolePermissions(t *testing.T) {
+	s := newTestServer(t, true)
+	s.addUser("admin", auth.RoleAdmin)
+	s.addUser("viewer", auth.RoleViewer)
+	s.addUser("operator", auth.RoleOperator)
+	admin, viewer, operator := s.login("admin"), s.login("viewer"), s.login("operator")
+
+	s.expect(401, "GET", "/api/v1/sites", nil, nil)
+	s.expect(401, "GET", "/api/v1/sites", nil, http.Header{"Authorization": {"Bearer invalid"}})
+
+	id := s.createSite("站点", admin)
+	s.expect(200, "GET", "/api/v1/sites", nil, viewer)
+	s.expect(403, "DELETE", path("/sites/%d", id), nil, viewer)
+	s.expect(200, "PUT", path("/sites/%d/toggle", id), nil, operator)
+	s.expect(403, "PUT", "/api/v1/system/config", gin.H{"a": 1}, operator)
+	s.expect(403, "GET", "/api/v1/users", nil, operator)
+	s.expect(200, "GET", "/api/v1/users", nil, admin)
+
+	// 注销后令牌失效
+	s.expect(200, "POST", "/api/v1/auth/logout", nil, viewer)
+	s.expect(401, "GET", "/api/v1/sites", nil, viewer)
+}
+
+func TestAPIKeySiteRestriction(t *testing.T) {
+	s := newTestServer(t, true)
+	s.addUser("admin", auth.RoleAdmin)
+	admin := s.login("admin")
+
+	siteA := s.createSite("站点A", admin)
+	siteB := s.createSite("站点B", admin)
+	s.addItem(siteA, "https://example.com/a")
+	itemB := s.addItem(siteB, "https://example.com/b")
+
+	s.expect(400, "POST", "/api/v1/system/api-keys", gin.H{"name": "k", "scopes": []string{"unknown"}}, admin)
+	var created struct {
+		Key string `json:"key"`
+	}
+	decodeBody(t, s.expect(201, "POST", "/api/v1/system/api-keys", gin.H{
+		"name":     "export",
+		"scopes":   []string{auth.ScopeDataRead},
+		"site_ids": []int{siteA},
+	}, admin), &created)
+	key := http.Header{"X-Api-Key": {created.Key}}
+
+	var list struct {
+		Data []repository.Item `json:"data"`
+	}
+	decodeBody(t, s.expect(200, "GET", "/api/v1/data/items", nil, key), &list)
+	if len(list.Data) != 1 || list.Data[0].SiteID != siteA {
+		t.Fatalf("密钥应只能看到站点A的数据: %+v", list.Data)
+	}
+	s.expect(403, "GET", path("/data/items/%d", itemB.ID), nil, key)
+	s.expect(403, "GET", "/api/v1/data/statistics", nil, key)
+	s.expect(403, "GET", "/api/v1/sites", nil, key)
+	s.expect(401, "GET", "/api/v1/data/items", nil, http.Header{"X-Api-Key": {"ck_invalid"}})
+}
+
+func TestAccessTokenQueryOnlyForStreams(t *testing.T) {
+	s := newTestServer(t, true)
+	s.addUser("admin", auth.RoleAdmin)
+	token := strings.TrimPrefix(s.login("admin").Get("Authorization"), "Bearer ")
+
+	s.expect(401, "GET", "/api/v1/sites?access_token="+token, nil, nil)
+	s.expect(401, "GET", "/api/v1/tasks/abc/stream", nil, nil)
+	// 通过认证后才校验任务ID
+	s.expect(400, "GET", "/api/v1/tasks/abc/stream?access_token="+token, nil, nil)
+}
+
+func TestRedactURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/api/v1/tasks/1/stream?access_token=secret", "/api/v1/tasks/1/stream?access_token=REDACTED"},
+		{"/api/v1/tasks/stream?a=1&access_token=secret", "/api/v1/tasks/stream?a=1&access_token=REDACTED"},
+		{"/api/v1/data/items?page=2&size=20", "/api/v1/data/items?page=2&size=20"},
+		{"/api/v1/sites", "/api/v1/sites"},
+		{"/api/v1/sites?access_token=secret;x=%zz", "/api/v1/sites"},
+	}
+	for _, tt := range tests {
+		if got := redactURI(tt.uri); got != tt.want {
+			t.Errorf("redactURI(%q) = %q，期望 %q", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	s := newTestServer(t, true)
+	s.addUser("admin", auth.RoleAdmin)
+
+	type tokens struct {
+		Tokens struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		} `json:"tokens"`
+	}
+	var login tokens
+	decodeBody(t, s.expect(200, "POST", "/api/v1/auth/login", gin.H{"username": "admin", "password": testPassword}, nil), &login)
+
+	// 刷新令牌换取新的访问令牌，两种令牌不能互换使用
+	var refreshed tokens
+	decodeBody(t, s.expect(200, "POST", "/api/v1/auth/refresh", gin.H{"refresh_token": login.Tokens.RefreshToken}, nil), &refreshed)
+	access := http.Header{"Authorization": {"Bearer " + refreshed.Tokens.AccessToken}}
+	s.expect(200, "GET", "/api/v1/auth/me", nil, access)
+	s.expect(401, "POST", "/api/v1/auth/refresh", gin.H{"refresh_token": login.Tokens.AccessToken}, nil)
+	s.expect(401, "GET", "/api/v1/auth/me", nil, http.Header{"Authorization": {"Bearer " + login.Tokens.RefreshToken}})
+
+	// 注销后会话下的刷新令牌同样失效
+	s.expect(200, "POST", "/api/v1/auth/logout", nil, access)
+	s.expect(401, "POST", "/api/v1/auth/refresh", gin.H{"refresh_token": refreshed.Tokens.RefreshToken}, nil)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/limited", RateLimitMiddleware(ratelimit.NewMemoryStore(), "test", ratelimit.Limit{PerMinute: 1}, testLogger{t}), func(c *gin.Context) {
+		c.Status(200)
+	})
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/limited", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("10.0.0.1"); w.Code != 200 || w.Header().Get("X-RateLimit-Remaining") != "0" || w.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Fatalf("第一次请求: %d %v", w.Code, w.Header())
+	}
+	if w := request("10.0.0.1"); w.Code != 429 || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("超出限额: %d %v", w.Code, w.Header())
+	}
+	// 不同客户端分别计数
+	if w := request("10.0.0.2"); w.Code != 200 {
+		t.Fatalf("其他客户端: %d", w.Code)
+	}
+}
@@ -1,69 +1,139 @@
 package api
 
 import (
-	"database/sql"
-
+	"example.com/m/v2/internal/auth"
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/ratelimit"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
 	"example.com/m/v2/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes 设置API路由
-func SetupRoutes(r *gin.RouterGroup, db *sql.DB, logger utils.Logger, cfg *config.Config) {
+// SetupRoutes 设置API路由。tokens 为 nil 时不启用认证，否则除登录、健康检查和版本信息外的接口都需要登录；
+// limiter 为 nil 时不限流
+func SetupRoutes(r *gin.RouterGroup, repos *repository.Repositories, logger utils.Logger, cfg *config.Config, taskRunner *runner.Runner, tokens *auth.Manager, limiter ratelimit.Store) {
 	// 创建控制器
-	siteController := NewSiteController(db, logger, cfg)
-	taskController := NewTaskController(db, logger, cfg)
-	dataController := NewDataController(db, logger)
-	systemController := NewSystemController(db, logger, cfg)
+	siteController := NewSiteController(repos, logger, cfg, taskRunner)
+	taskController := NewTaskController(repos, logger, cfg, taskRunner)
+	dataController := NewDataController(repos, logger)
+	systemController := NewSystemController(repos, logger, cfg)
+	toolsController := NewToolsController(logger, cfg)
+	auditController := NewAuditController(repos, logger)
+	record := auditController.Record
+
+	// limit 按名称限流，limiter 为 nil（未启用限流）或每分钟请求数不大于 0 时不检查
+	rateLimit := cfg.Web.RateLimit
+	limit := func(name string, perMinute, burst int) gin.HandlerFunc {
+		if limiter == nil || perMinute <= 0 {
+			return pass
+		}
+		return RateLimitMiddleware(limiter, name, ratelimit.Limit{PerMinute: perMinute, Burst: burst}, logger)
+	}
+	defaultLimit := limit("default", rateLimit.RequestsPerMinute, rateLimit.Burst)
+
+	// 认证路由
+	protected := r.Group("", defaultLimit)
+	if tokens != nil {
+		authController := NewAuthController(repos, logger, tokens)
+		r.POST("/auth/login", defaultLimit, authController.Login)
+		r.POST("/auth/refresh", defaultLimit, authController.Refresh)
+
+		protected = r.Group("", AuthMiddleware(authController), defaultLimit)
+		protected.POST("/auth/logout", authController.Logout)
+		protected.GET("/auth/me", authController.Me)
+		protected.GET("/auth/roles", authController.ListRoles)
+	}
+
+	// allow 按权限限制访问，未启用认证时不检查
+	allow := func(perm auth.Permission) gin.HandlerFunc {
+		if tokens == nil {
+			return pass
+		}
+		return RequirePermission(perm)
+	}
 
 	// 站点管理路由
-	sites := r.Group("/sites")
+	sites := protected.Group("/sites")
 	{
-		sites.GET("", siteController.ListSites)
-		sites.POST("", siteController.CreateSite)
-		sites.GET("/:id", siteController.GetSite)
-		sites.PUT("/:id", siteController.UpdateSite)
-		sites.DELETE("/:id", siteController.DeleteSite)
-		sites.POST("/:id/test", siteController.TestSite)
-		sites.PUT("/:id/toggle", siteController.ToggleSite)
-		sites.POST("/:id/run", siteController.RunSiteTask) // 添加运行任务的路由
+		sites.GET("", allow(auth.PermSitesRead), siteController.ListSites)
+		sites.POST("", allow(auth.PermSitesWrite), record("site.create", auditController.siteTarget()), siteController.CreateSite)
+		sites.GET("/:id", allow(auth.PermSitesRead), siteController.GetSite)
+		sites.PUT("/:id", allow(auth.PermSitesWrite), record("site.update", auditController.siteTarget()), siteController.UpdateSite)
+		sites.DELETE("/:id", allow(auth.PermSitesWrite), record("site.delete", auditController.siteTarget()), siteController.DeleteSite)
+		sites.POST("/:id/test", allow(auth.PermSitesWrite), siteController.TestSite)
+		sites.PUT("/:id/toggle", allow(auth.PermSitesOperate), record("site.toggle", auditController.siteTarget()), siteController.ToggleSite)
+		sites.POST("/:id/run", allow(auth.PermTasksOperate), limit("run", rateLimit.RunPerMinute, 0),
+			record("site.run", auditController.siteTarget()), siteController.RunSiteTask) // 添加运行任务的路由
+		sites.GET("/:id/schedule", allow(auth.PermSitesRead), siteController.GetSiteSchedule)
+		sites.PUT("/:id/schedule", allow(auth.PermSitesWrite), record("site.schedule", auditController.scheduleTarget()), siteController.UpdateSiteSchedule)
 	}
 
 	// 任务管理路由
-	tasks := r.Group("/tasks")
+	tasks := protected.Group("/tasks")
 	{
-		tasks.GET("", taskController.ListTasks)
-		tasks.POST("", taskController.CreateTask)
-		tasks.GET("/:id", taskController.GetTask)
-		tasks.PUT("/:id", taskController.UpdateTask)
-		tasks.DELETE("/:id", taskController.DeleteTask)
-		tasks.POST("/:id/start", taskController.StartTask)
-		tasks.POST("/:id/stop", taskController.StopTask)
-		tasks.GET("/:id/logs", taskController.GetTaskLogs)
-		tasks.GET("/:id/status", taskController.GetTaskStatus)
+		tasks.GET("", allow(auth.PermTasksRead), taskController.ListTasks)
+		tasks.GET("/stream", allow(auth.PermTasksRead), taskController.StreamTaskEvents) // 所有任务的状态变化
+		tasks.POST("", allow(auth.PermTasksWrite), record("task.create", auditController.taskTarget()), taskController.CreateTask)
+		tasks.GET("/:id", allow(auth.PermTasksRead), taskController.GetTask)
+		tasks.PUT("/:id", allow(auth.PermTasksWrite), record("task.update", auditController.taskTarget()), taskController.UpdateTask)
+		tasks.DELETE("/:id", allow(auth.PermTasksWrite), record("task.delete", auditController.taskTarget()), taskController.DeleteTask)
+		tasks.POST("/:id/start", allow(auth.PermTasksOperate), record("task.start", auditController.taskTarget()), taskController.StartTask)
+		tasks.POST("/:id/stop", allow(auth.PermTasksOperate), record("task.stop", auditController.taskTarget()), taskController.StopTask)
+		tasks.GET("/:id/logs", allow(auth.PermTasksRead), taskController.GetTaskLogs)
+		tasks.GET("/:id/status", allow(auth.PermTasksRead), taskController.GetTaskStatus)
+		tasks.GET("/:id/stream", allow(auth.PermTasksRead), taskController.StreamTask)
 	}
 
 	// 数据管理路由
-	data := r.Group("/data")
+	data := protected.Group("/data")
 	{
-		data.GET("/items", dataController.ListItems)
-		data.GET("/items/:id", dataController.GetItem)
-		data.DELETE("/items/:id", dataController.DeleteItem)
-		data.GET("/items/export", dataController.ExportItems)
-		data.GET("/statistics", dataController.GetStatistics)
-		data.POST("/items/search", dataController.SearchItems)
+		data.GET("/items", allow(auth.PermDataRead), dataController.ListItems)
+		data.GET("/items/:id", allow(auth.PermDataRead), dataController.GetItem)
+		data.DELETE("/items/:id", allow(auth.PermDataWrite), dataController.DeleteItem)
+		data.GET("/items/export", allow(auth.PermDataRead), limit("export", rateLimit.ExportPerMinute, 0), dataController.ExportItems)
+		data.POST("/items/import", allow(auth.PermDataWrite), dataController.ImportItems)
+		data.GET("/statistics", allow(auth.PermDataRead), dataController.GetStatistics)
+		data.POST("/items/search", allow(auth.PermDataRead), limit("search", rateLimit.SearchPerMinute, 0), dataController.SearchItems)
 	}
 
 	// 系统管理路由
-	system := r.Group("/system")
+	system := protected.Group("/system")
 	{
-		system.GET("/status", systemController.GetSystemStatus)
-		system.GET("/config", systemController.GetConfig)
-		system.PUT("/config", systemController.UpdateConfig)
-		system.GET("/logs", systemController.GetLogs)
-		system.POST("/backup", systemController.CreateBackup)
-		system.GET("/backups", systemController.ListBackups)
-		system.POST("/restore", systemController.RestoreBackup)
+		system.GET("/status", allow(auth.PermSystemRead), systemController.GetSystemStatus)
+		system.GET("/config", allow(auth.PermSystemAdmin), systemController.GetConfig)
+		system.PUT("/config", allow(auth.PermSystemAdmin), record("config.update", auditController.configTarget()), systemController.UpdateConfig)
+		system.GET("/logs", allow(auth.PermSystemRead), systemController.GetLogs)
+		system.POST("/backup", allow(auth.PermSystemAdmin), record("backup.create", auditController.backupTarget()), systemController.CreateBackup)
+		system.GET("/backups", allow(auth.PermSystemAdmin), systemController.ListBackups)
+		system.POST("/restore", allow(auth.PermSystemAdmin), record("backup.restore", auditController.backupTarget()), systemController.RestoreBackup)
+		system.GET("/audit", allow(auth.PermSystemAdmin), auditController.ListAuditLog)
+	}
+
+	// 工具路由
+	tools := protected.Group("/tools")
+	{
+		tools.POST("/extract", allow(auth.PermToolsUse), toolsController.Extract)
+	}
+
+	// 用户和API密钥管理路由，仅在启用认证时可用
+	if tokens != nil {
+		userController := NewUserController(repos, logger)
+		users := protected.Group("/users", allow(auth.PermUsersManage))
+		{
+			users.GET("", userController.ListUsers)
+			users.POST("", record("user.create", auditController.userTarget()), userController.CreateUser)
+			users.GET("/:id", userController.GetUser)
+			users.PUT("/:id", record("user.update", auditController.userTarget()), userController.UpdateUser)
+		}
+
+		apiKeyController := NewAPIKeyController(repos, logger)
+		apiKeys := system.Group("/api-keys", allow(auth.PermSystemAdmin))
+		{
+			apiKeys.GET("", apiKeyController.ListAPIKeys)
+			apiKeys.POST("", record("api_key.create", auditController.apiKeyTarget()), apiKeyController.CreateAPIKey)
+			apiKeys.DELETE("/:id", record("api_key.delete", auditController.apiKeyTarget()), apiKeyController.DeleteAPIKey)
+		}
 	}
 
 	// 健康检查
@@ -83,3 +153,8 @@ func SetupRoutes(r *gin.RouterGroup, db *sql.DB, logger utils.Logger, cfg *confi
 		})
 	})
 }
+
+// pass 不做任何检查的中间件，用于未启用的认证或限流
+func pass(c *gin.Context) {
+	c.Next()
+}
@@ -1,14 +1,15 @@
 package api
 
 import (
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"strconv"
 	"time"
 
 	"example.com/m/v2/internal/config"
 	"example.com/m/v2/internal/crawler"
-	"example.com/m/v2/internal/storage"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
+	"example.com/m/v2/internal/scheduler"
 	"example.com/m/v2/internal/utils"
 	"example.com/m/v2/pkg/models"
 	"github.com/gin-gonic/gin"
@@ -16,17 +17,19 @@ import (
 
 // SiteController 站点控制器
 type SiteController struct {
-	db     *sql.DB
+	repos  *repository.Repositories
 	logger utils.Logger
 	config *config.Config
+	runner *runner.Runner
 }
 
 // NewSiteController 创建站点控制器
-func NewSiteController(db *sql.DB, logger utils.Logger, cfg *config.Config) *SiteController {
+func NewSiteController(repos *repository.Repositories, logger utils.Logger, cfg *config.Config, taskRunner *runner.Runner) *SiteController {
 	return &SiteController{
-		db:     db,
+		repos:  repos,
 		logger: logger,
 		config: cfg,
+		runner: taskRunner,
 	}
 }
 
@@ -42,105 +45,50 @@ type SiteRequest struct {
 }
 
 // SiteRules 站点规则
-type SiteRules struct {
-	MaxDepth     int      `json:"max_depth"`
-	MaxPages     int      `json:"max_pages"`
-	URLPatterns  []string `json:"url_patterns"`
-	ContentTypes []string `json:"content_types"`
-	Concurrent   int      `json:"concurrent"`
-	Delay        int      `json:"delay"`
-}
+type SiteRules = repository.SiteRules
 
 // SiteResponse 站点响应结构
-type SiteResponse struct {
-	ID          int               `json:"id"`
-	Name        string            `json:"name"`
-	BaseURL     string            `json:"base_url"`
-	Description string            `json:"description"`
-	StartURLs   []string          `json:"start_urls"`
-	Selectors   map[string]string `json:"selectors"`
-	Rules       SiteRules         `json:"rules"`
-	Enabled     bool              `json:"enabled"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	Status      string            `json:"status"`
-	LastRunAt   *time.Time        `json:"last_run_at"`
+type SiteResponse = repository.Site
+
+// site 将请求转换为站点记录
+func (req *SiteRequest) site() *repository.Site {
+	return &repository.Site{
+		Name:        req.Name,
+		BaseURL:     req.BaseURL,
+		Description: req.Description,
+		StartURLs:   req.StartURLs,
+		Selectors:   req.Selectors,
+		Rules:       req.Rules,
+		Enabled:     req.Enabled,
+	}
+}
+
+// ScheduleRequest 站点调度请求结构
+type ScheduleRequest struct {
+	Schedule string `json:"schedule"` // cron表达式或时间间隔，如 "0 * * * *"、"@every 30m"、"1h"
+	Enabled  bool   `json:"enabled"`
 }
 
+// ScheduleResponse 站点调度响应结构
+type ScheduleResponse = repository.Schedule
+
 // ListSites 获取站点列表
 func (sc *SiteController) ListSites(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	enabled := c.Query("enabled")
 
-	offset := (page - 1) * pageSize
-
-	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
-
-	if enabled != "" {
-		where += " AND enabled = ?"
-		args = append(args, enabled == "true")
-	}
-
-	// 查询总数
-	var total int
-	countQuery := "SELECT COUNT(*) FROM sites WHERE " + where
-	err := sc.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		sc.logger.Error("查询站点总数失败", "error", err)
-		c.JSON(500, gin.H{"error": "查询失败"})
-		return
+	filter := repository.SiteFilter{Pagination: repository.Pagination{Page: page, PageSize: pageSize}}
+	if enabled := c.Query("enabled"); enabled != "" {
+		value := enabled == "true"
+		filter.Enabled = &value
 	}
 
-	// 查询站点列表
-	query := `
-		SELECT id, name, base_url, description, start_urls, selectors, rules, 
-		       enabled, created_at, updated_at, status, last_run_at
-		FROM sites 
-		WHERE ` + where + `
-		ORDER BY created_at DESC 
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, pageSize, offset)
-
-	rows, err := sc.db.Query(query, args...)
+	sites, total, err := sc.repos.Sites.List(filter)
 	if err != nil {
 		sc.logger.Error("查询站点列表失败", "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-	defer rows.Close()
-
-	var sites []SiteResponse
-	for rows.Next() {
-		var site SiteResponse
-		var startURLsJSON, selectorsJSON, rulesJSON string
-		var lastRunAt sql.NullTime
-
-		err := rows.Scan(
-			&site.ID, &site.Name, &site.BaseURL, &site.Description,
-			&startURLsJSON, &selectorsJSON, &rulesJSON,
-			&site.Enabled, &site.CreatedAt, &site.UpdatedAt,
-			&site.Status, &lastRunAt,
-		)
-		if err != nil {
-			sc.logger.Error("扫描站点数据失败", "error", err)
-			continue
-		}
-
-		// 解析JSON字段
-		json.Unmarshal([]byte(startURLsJSON), &site.StartURLs)
-		json.Unmarshal([]byte(selectorsJSON), &site.Selectors)
-		json.Unmarshal([]byte(rulesJSON), &site.Rules)
-
-		if lastRunAt.Valid {
-			site.LastRunAt = &lastRunAt.Time
-		}
-
-		sites = append(sites, site)
-	}
 
 	c.JSON(200, gin.H{
 		"data": sites,
@@ -162,41 +110,30 @@ func (sc *SiteController) CreateSite(c *gin.Context) {
 	}
 
 	// 检查站点名称是否已存在
-	var count int
-	err := sc.db.QueryRow("SELECT COUNT(*) FROM sites WHERE name = ?", req.Name).Scan(&count)
+	exists, err := sc.repos.Sites.ExistsByName(req.Name)
 	if err != nil {
 		sc.logger.Error("检查站点名称失败", "error", err)
 		c.JSON(500, gin.H{"error": "服务器错误"})
 		return
 	}
-	if count > 0 {
+	if exists {
 		c.JSON(400, gin.H{"error": "站点名称已存在"})
 		return
 	}
 
-	// 序列化JSON字段
-	startURLsJSON, _ := json.Marshal(req.StartURLs)
-	selectorsJSON, _ := json.Marshal(req.Selectors)
-	rulesJSON, _ := json.Marshal(req.Rules)
-
-	// 插入站点
-	query := `
-		INSERT INTO sites (name, base_url, description, start_urls, selectors, rules, enabled, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 'ready', NOW(), NOW())
-	`
-	result, err := sc.db.Exec(query, req.Name, req.BaseURL, req.Description, startURLsJSON, selectorsJSON, rulesJSON, req.Enabled)
-	if err != nil {
+	site := req.site()
+	if err := sc.repos.Sites.Create(site); err != nil {
 		sc.logger.Error("创建站点失败", "error", err)
 		c.JSON(500, gin.H{"error": "创建失败"})
 		return
 	}
 
-	id, _ := result.LastInsertId()
-	sc.logger.Info("创建站点成功", "id", id, "name", req.Name)
+	sc.logger.Info("创建站点成功", "id", site.ID, "name", req.Name)
+	setAuditTarget(c, site.ID)
 
 	c.JSON(201, gin.H{
 		"message": "站点创建成功",
-		"id":      id,
+		"id":      site.ID,
 	})
 }
 
@@ -208,13 +145,8 @@ func (sc *SiteController) GetSite(c *gin.Context) {
 		return
 	}
 
-	site, err := sc.getSiteByID(id)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(404, gin.H{"error": "站点不存在"})
-		} else {
-			c.JSON(500, gin.H{"error": "查询失败"})
-		}
+	site, ok := sc.getSite(c, id)
+	if !ok {
 		return
 	}
 
@@ -235,26 +167,13 @@ func (sc *SiteController) UpdateSite(c *gin.Context) {
 		return
 	}
 
-	// 检查站点是否存在
-	var count int
-	err = sc.db.QueryRow("SELECT COUNT(*) FROM sites WHERE id = ?", id).Scan(&count)
-	if err != nil || count == 0 {
+	site := req.site()
+	site.ID = id
+	err = sc.repos.Sites.Update(site)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(404, gin.H{"error": "站点不存在"})
 		return
 	}
-
-	// 序列化JSON字段
-	startURLsJSON, _ := json.Marshal(req.StartURLs)
-	selectorsJSON, _ := json.Marshal(req.Selectors)
-	rulesJSON, _ := json.Marshal(req.Rules)
-
-	// 更新站点
-	query := `
-		UPDATE sites 
-		SET name=?, base_url=?, description=?, start_urls=?, selectors=?, rules=?, enabled=?, updated_at=NOW()
-		WHERE id=?
-	`
-	_, err = sc.db.Exec(query, req.Name, req.BaseURL, req.Description, startURLsJSON, selectorsJSON, rulesJSON, req.Enabled, id)
 	if err != nil {
 		sc.logger.Error("更新站点失败", "error", err)
 		c.JSON(500, gin.H{"error": "更新失败"})
@@ -273,47 +192,86 @@ func (sc *SiteController) DeleteSite(c *gin.Context) {
 		return
 	}
 
-	// 检查站点是否存在
-	var count int
-	err = sc.db.QueryRow("SELECT COUNT(*) FROM sites WHERE id = ?", id).Scan(&count)
-	if err != nil || count == 0 {
+	err = sc.repos.Sites.Delete(id)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(404, gin.H{"error": "站点不存在"})
 		return
 	}
-
-	// 删除站点
-	result, err := sc.db.Exec("DELETE FROM sites WHERE id = ?", id)
 	if err != nil {
 		sc.logger.Error("删除站点失败", "error", err)
 		c.JSON(500, gin.H{"error": "删除失败"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(404, gin.H{"error": "站点不存在"})
-		return
-	}
-
 	sc.logger.Info("站点已删除", "id", id)
 	c.JSON(200, gin.H{"message": "站点删除成功"})
 }
 
-// TestSite 测试站点规则（模拟运行）
+// TestSiteRequest 站点测试请求结构
+type TestSiteRequest struct {
+	URL string `json:"url"` // 测试的页面，留空时使用第一个起始URL
+}
+
+// TestSite 测试站点规则（模拟运行），抓取单个页面并返回提取结果，不写入数据
 func (sc *SiteController) TestSite(c *gin.Context) {
-	_, err := strconv.Atoi(c.Param("id")) // id 暂时未使用，用_忽略
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": "无效的站点ID"})
 		return
 	}
 
-	// TODO: 实现站点测试逻辑
-	// 1. 获取站点配置
-	// 2. 启动一个一次性的爬虫任务
-	// 3. 返回初步的抓取结果
+	var req TestSiteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	site, ok := sc.getSite(c, id)
+	if !ok {
+		return
+	}
+
+	testURL := req.URL
+	if testURL == "" && len(site.StartURLs) > 0 {
+		testURL = site.StartURLs[0]
+	}
+	if testURL == "" {
+		c.JSON(400, gin.H{"error": "站点没有起始URL，请指定测试URL"})
+		return
+	}
+
+	// 测试URL由请求指定，只允许访问公网地址
+	if err := crawler.CheckPublicURL(c.Request.Context(), testURL); err != nil {
+		sc.logger.Warn("拒绝测试页面", "site", site.Name, "url", testURL, "ip", c.ClientIP(), "error", err)
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	task := &models.CrawlTask{
+		SiteID:    site.ID,
+		Name:      site.Name,
+		BaseURL:   site.BaseURL,
+		StartURLs: site.StartURLs,
+		Selectors: site.Selectors,
+	}
+
+	spider := crawler.NewSpider(sc.config, nil, sc.logger)
+	result, err := spider.PreviewPublic(c.Request.Context(), task, testURL)
+	if errors.Is(err, crawler.ErrPrivateAddress) {
+		sc.logger.Warn("拒绝测试内网地址", "site", site.Name, "url", testURL, "ip", c.ClientIP())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		sc.logger.Error("站点测试失败", "site", site.Name, "url", testURL, "error", err)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 
-	sc.logger.Info("收到站点测试请求")
-	c.JSON(202, gin.H{"message": "测试任务已启动，请稍后查看结果"})
+	sc.logger.Info("站点测试完成", "site", site.Name, "url", testURL, "items", len(result.Items))
+	c.JSON(200, result)
 }
 
 // ToggleSite 切换站点启用状态
@@ -325,22 +283,14 @@ func (sc *SiteController) ToggleSite(c *gin.Context) {
 	}
 
 	// 获取当前状态
-	var enabled bool
-	err = sc.db.QueryRow("SELECT enabled FROM sites WHERE id = ?", id).Scan(&enabled)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "站点不存在"})
-		return
-	}
-	if err != nil {
-		sc.logger.Error("查询站点状态失败", "error", err)
-		c.JSON(500, gin.H{"error": "查询失败"})
+	site, ok := sc.getSite(c, id)
+	if !ok {
 		return
 	}
 
 	// 切换状态
-	newEnabled := !enabled
-	_, err = sc.db.Exec("UPDATE sites SET enabled = ?, updated_at = NOW() WHERE id = ?", newEnabled, id)
-	if err != nil {
+	newEnabled := !site.Enabled
+	if err := sc.repos.Sites.SetEnabled(id, newEnabled); err != nil {
 		sc.logger.Error("切换站点状态失败", "error", err)
 		c.JSON(500, gin.H{"error": "操作失败"})
 		return
@@ -358,103 +308,121 @@ func (sc *SiteController) ToggleSite(c *gin.Context) {
 	})
 }
 
-// RunSiteTask 运行单个站点爬虫任务
-func (sc *SiteController) RunSiteTask(c *gin.Context) {
+// GetSiteSchedule 获取站点调度配置
+func (sc *SiteController) GetSiteSchedule(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": "无效的站点ID"})
 		return
 	}
 
-	site, err := sc.getSiteByID(id)
+	schedule, err := sc.repos.Sites.GetSchedule(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "站点不存在"})
+		return
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(404, gin.H{"error": "站点不存在"})
-		} else {
-			c.JSON(500, gin.H{"error": "查询失败"})
-		}
+		sc.logger.Error("查询站点调度失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
 
-	if !site.Enabled {
-		c.JSON(400, gin.H{"error": "该站点已被禁用，无法运行任务"})
+	c.JSON(200, schedule)
+}
+
+// UpdateSiteSchedule 更新站点调度配置
+func (sc *SiteController) UpdateSiteSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的站点ID"})
 		return
 	}
 
-	go func() {
-		sc.logger.Info("开始后台爬虫任务", "site", site.Name)
-		// 每次任务创建独立的存储和爬虫实例
-		store, err := storage.NewStorage(sc.config.Storage)
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 启用调度时必须提供有效的调度规则，并立即计算下次运行时间
+	update := &repository.Schedule{SiteID: id, Schedule: req.Schedule, Enabled: req.Enabled}
+	if req.Enabled || req.Schedule != "" {
+		schedule, err := scheduler.ParseSchedule(req.Schedule)
 		if err != nil {
-			sc.logger.Error("创建存储实例失败", "error", err, "site", site.Name)
+			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
-		defer store.Close()
-
-		spider := crawler.NewSpider(sc.config, store, sc.logger)
-
-		// 更新任务状态为 'running'
-		sc.db.Exec("UPDATE sites SET status = 'running', last_run_at = NOW() WHERE id = ?", site.ID)
-
-		// 将 api.SiteResponse 转换为 models.CrawlTask
-		task := &models.CrawlTask{
-			ID:        site.ID,
-			Name:      site.Name,
-			BaseURL:   site.BaseURL,
-			StartURLs: site.StartURLs,
-			Selectors: site.Selectors,
-			Rules: models.CrawlTaskRules{
-				MaxDepth:   site.Rules.MaxDepth,
-				MaxPages:   site.Rules.MaxPages,
-				Concurrent: site.Rules.Concurrent,
-				Delay:      site.Rules.Delay,
-			},
+		if req.Enabled {
+			next := schedule.Next(time.Now())
+			update.NextRunAt = &next
 		}
+	}
 
-		if err := spider.StartWithTask(task); err != nil {
-			sc.logger.Error("爬虫任务执行失败", "error", err, "site", site.Name)
-		} else {
-			sc.logger.Info("爬虫任务成功完成", "site", site.Name)
-		}
+	err = sc.repos.Sites.UpdateSchedule(update)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "站点不存在"})
+		return
+	}
+	if err != nil {
+		sc.logger.Error("更新站点调度失败", "error", err)
+		c.JSON(500, gin.H{"error": "更新失败"})
+		return
+	}
 
-		// 任务结束后更新状态为 'ready'
-		sc.db.Exec("UPDATE sites SET status = 'ready' WHERE id = ?", site.ID)
-	}()
+	schedule, err := sc.repos.Sites.GetSchedule(id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "获取更新后的调度失败"})
+		return
+	}
 
-	c.JSON(202, gin.H{"message": "爬虫任务已在后台启动"})
+	sc.logger.Info("站点调度已更新", "id", id, "schedule", req.Schedule, "enabled", req.Enabled)
+	c.JSON(200, schedule)
 }
 
-// getSiteByID 是一个辅助函数，用于通过ID获取站点信息
-func (sc *SiteController) getSiteByID(id int) (*SiteResponse, error) {
-	query := `
-		SELECT id, name, base_url, description, start_urls, selectors, rules, 
-		       enabled, created_at, updated_at, status, last_run_at
-		FROM sites WHERE id = ?
-	`
-
-	var site SiteResponse
-	var startURLsJSON, selectorsJSON, rulesJSON string
-	var lastRunAt sql.NullTime
-
-	err := sc.db.QueryRow(query, id).Scan(
-		&site.ID, &site.Name, &site.BaseURL, &site.Description,
-		&startURLsJSON, &selectorsJSON, &rulesJSON,
-		&site.Enabled, &site.CreatedAt, &site.UpdatedAt,
-		&site.Status, &lastRunAt,
-	)
+// RunSiteTask 运行单个站点爬虫任务
+func (sc *SiteController) RunSiteTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		sc.logger.Error("查询站点详情失败", "id", id, "error", err)
-		return nil, err
+		c.JSON(400, gin.H{"error": "无效的站点ID"})
+		return
+	}
+
+	site, ok := sc.getSite(c, id)
+	if !ok {
+		return
 	}
 
-	// 解析JSON字段
-	json.Unmarshal([]byte(startURLsJSON), &site.StartURLs)
-	json.Unmarshal([]byte(selectorsJSON), &site.Selectors)
-	json.Unmarshal([]byte(rulesJSON), &site.Rules)
+	if !site.Enabled {
+		c.JSON(400, gin.H{"error": "该站点已被禁用，无法运行任务"})
+		return
+	}
 
-	if lastRunAt.Valid {
-		site.LastRunAt = &lastRunAt.Time
+	// 为本次运行创建任务记录，由任务执行器在后台驱动爬虫
+	taskID, err := sc.runner.RunSite(site.ID, site.Name)
+	if err != nil {
+		sc.logger.Error("启动爬虫任务失败", "site", site.Name, "task_id", taskID, "error", err)
+		c.JSON(500, gin.H{"error": "启动任务失败"})
+		return
 	}
 
-	return &site, nil
+	auditDetail(c, "task_id", taskID)
+	c.JSON(202, gin.H{"message": "爬虫任务已在后台启动", "task_id": taskID})
+}
+
+// getSite 查询站点并检查当前请求能否访问，失败时写入错误响应并返回 false
+func (sc *SiteController) getSite(c *gin.Context, id int) (*SiteResponse, bool) {
+	if !siteAllowed(c, id) {
+		return nil, false
+	}
+	site, err := sc.repos.Sites.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "站点不存在"})
+		return nil, false
+	}
+	if err != nil {
+		sc.logger.Error("查询站点详情失败", "id", id, "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	return site, true
 }
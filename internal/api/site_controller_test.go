@@ -0,0 +1,104 @@
+package api
+
+import (
+	"testing"
+
+	"example.com/m/v2/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSiteLifecycle(t *testing.T) {
+	s := newTestServer(t, false)
+
+	id := s.createSite("示例站点", nil)
+	s.expect(400, "POST", "/api/v1/sites", gin.H{
+		"name":       "示例站点",
+		"base_url":   "https://example.com",
+		"start_urls": []string{"https://example.com/"},
+		"selectors":  gin.H{"title": "h1"},
+	}, nil)
+
+	var list struct {
+		Data       []repository.Site `json:"data"`
+		Pagination struct {
+			Total int `json:"total"`
+		} `json:"pagination"`
+	}
+	decodeBody(t, s.expect(200, "GET", "/api/v1/sites", nil, nil), &list)
+	if list.Pagination.Total != 1 || len(list.Data) != 1 || list.Data[0].ID != id {
+		t.Fatalf("站点列表不符: %+v", list)
+	}
+
+	s.expect(200, "PUT", path("/sites/%d", id), gin.H{
+		"name":       "示例站点",
+		"base_url":   "https://example.org",
+		"start_urls": []string{"https://example.org/"},
+		"selectors":  gin.H{"title": "h2"},
+		"enabled":    true,
+	}, nil)
+	var got struct {
+		Data repository.Site `json:"data"`
+	}
+	decodeBody(t, s.expect(200, "GET", path("/sites/%d", id), nil, nil), &got)
+	if got.Data.BaseURL != "https://example.org" || got.Data.Selectors["title"] != "h2" {
+		t.Fatalf("站点未更新: %+v", got.Data)
+	}
+
+	var toggled struct {
+		Enabled bool `json:"enabled"`
+	}
+	decodeBody(t, s.expect(200, "PUT", path("/sites/%d/toggle", id), nil, nil), &toggled)
+	if toggled.Enabled {
+		t.Fatal("切换后站点应为禁用")
+	}
+
+	s.expect(400, "PUT", path("/sites/%d/schedule", id), gin.H{"schedule": "30s", "enabled": true}, nil)
+	var schedule repository.Schedule
+	decodeBody(t, s.expect(200, "PUT", path("/sites/%d/schedule", id), gin.H{"schedule": "@hourly", "enabled": true}, nil), &schedule)
+	if schedule.Schedule != "@hourly" || !schedule.Enabled || schedule.NextRunAt == nil {
+		t.Fatalf("调度配置不符: %+v", schedule)
+	}
+
+	// 删除站点时级联删除其任务和数据
+	s.expect(200, "PUT", path("/sites/%d/toggle", id), nil, nil)
+	s.expect(201, "POST", "/api/v1/tasks", gin.H{"name": "任务", "site_id": id}, nil)
+	item := s.addItem(id, "https://example.org/a")
+	s.expect(200, "DELETE", path("/sites/%d", id), nil, nil)
+	s.expect(404, "GET", path("/sites/%d", id), nil, nil)
+	s.expect(404, "GET", path("/data/items/%d", item.ID), nil, nil)
+	if count, _ := s.repos.Tasks.Count(repository.TaskFilter{}); count != 0 {
+		t.Fatalf("站点删除后仍有 %d 个任务", count)
+	}
+}
+
+func TestSiteNotFound(t *testing.T) {
+	s := newTestServer(t, false)
+
+	s.expect(400, "GET", "/api/v1/sites/abc", nil, nil)
+	s.expect(404, "GET", "/api/v1/sites/42", nil, nil)
+	s.expect(404, "PUT", "/api/v1/sites/42/toggle", nil, nil)
+	s.expect(404, "DELETE", "/api/v1/sites/42", nil, nil)
+}
+
+func TestSiteTestRejectsPrivateAddresses(t *testing.T) {
+	s := newTestServer(t, false)
+	id := s.createSite("站点", nil)
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/admin",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::1]/",
+		"file:///etc/passwd",
+	} {
+		s.expect(400, "POST", path("/sites/%d/test", id), gin.H{"url": url}, nil)
+	}
+
+	// 起始URL同样检查
+	site, _ := s.repos.Sites.Get(id)
+	site.StartURLs = []string{"http://localhost/"}
+	if err := s.repos.Sites.Update(site); err != nil {
+		t.Fatalf("更新站点失败: %v", err)
+	}
+	s.expect(400, "POST", path("/sites/%d/test", id), nil, nil)
+}
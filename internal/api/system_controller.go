@@ -1,29 +1,29 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
 	"runtime"
 	"strconv"
-	"strings"
 	"time"
 
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/repository"
 	"example.com/m/v2/internal/utils"
+	"example.com/m/v2/pkg/constants"
 	"github.com/gin-gonic/gin"
 )
 
 // SystemController 系统控制器
 type SystemController struct {
-	db     *sql.DB
+	repos  *repository.Repositories
 	logger utils.Logger
 	config *config.Config
 }
 
 // NewSystemController 创建系统控制器
-func NewSystemController(db *sql.DB, logger utils.Logger, cfg *config.Config) *SystemController {
+func NewSystemController(repos *repository.Repositories, logger utils.Logger, cfg *config.Config) *SystemController {
 	return &SystemController{
-		db:     db,
+		repos:  repos,
 		logger: logger,
 		config: cfg,
 	}
@@ -37,17 +37,16 @@ func (sc *SystemController) GetSystemStatus(c *gin.Context) {
 
 	// 数据库连接状态
 	dbStatus := "connected"
-	if err := sc.db.Ping(); err != nil {
+	if err := sc.repos.Ping(); err != nil {
 		dbStatus = "disconnected"
 	}
 
 	// 运行中的任务数
-	var runningTasks int
-	sc.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = 'running'").Scan(&runningTasks)
+	runningTasks, _ := sc.repos.Tasks.Count(repository.TaskFilter{Status: constants.SpiderStatusRunning})
 
 	// 活跃站点数
-	var activeSites int
-	sc.db.QueryRow("SELECT COUNT(*) FROM sites WHERE enabled = TRUE").Scan(&activeSites)
+	enabled := true
+	activeSites, _ := sc.repos.Sites.Count(repository.SiteFilter{Enabled: &enabled})
 
 	// 系统负载（简化版）
 	cpuUsage := 0.0 // TODO: 实现CPU使用率计算
@@ -83,6 +82,10 @@ func (sc *SystemController) GetConfig(c *gin.Context) {
 			"timeout":    sc.config.Spider.Timeout,
 			"retries":    sc.config.Spider.Retries,
 			"proxy_url":  sc.config.Spider.ProxyURL,
+			"max_depth":  sc.config.Spider.MaxDepth,
+			"max_pages":  sc.config.Spider.MaxPages,
+
+			"respect_robots": sc.config.Spider.RespectRobots,
 		},
 		"storage": map[string]interface{}{
 			"type":       sc.config.Storage.Type,
@@ -125,13 +128,7 @@ func (sc *SystemController) UpdateConfig(c *gin.Context) {
 	}
 
 	// 更新或插入配置
-	_, err = sc.db.Exec(`
-		INSERT INTO system_config (config_key, config_value, description, updated_at)
-		VALUES ('system_config', ?, '系统配置', NOW())
-		ON DUPLICATE KEY UPDATE config_value = ?, updated_at = NOW()
-	`, configJSON, configJSON)
-
-	if err != nil {
+	if err := sc.repos.Config.Save("system_config", string(configJSON), "系统配置"); err != nil {
 		sc.logger.Error("更新系统配置失败", "error", err)
 		c.JSON(500, gin.H{"error": "更新失败"})
 		return
@@ -161,53 +158,30 @@ func (sc *SystemController) GetLogs(c *gin.Context) {
 		pageSize = 200
 	}
 
-	offset := (page - 1) * pageSize
-
-	// 使用 strings.Builder 安全地构建查询
-	var queryBuilder strings.Builder
-	args := []interface{}{}
-
-	queryBuilder.WriteString("SELECT level, message, details, created_at FROM task_logs WHERE 1=1")
-
-	if level != "" {
-		queryBuilder.WriteString(" AND level = ?")
-		args = append(args, level)
-	}
-
-	queryBuilder.WriteString(" ORDER BY created_at DESC LIMIT ? OFFSET ?")
-	args = append(args, pageSize, offset)
-
-	rows, err := sc.db.Query(queryBuilder.String(), args...)
+	rows, err := sc.repos.Logs.List(repository.LogFilter{
+		Level:      level,
+		Pagination: repository.Pagination{Page: page, PageSize: pageSize},
+	})
 	if err != nil {
 		sc.logger.Error("查询系统日志失败", "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-	defer rows.Close()
 
 	var logs []map[string]interface{}
-	for rows.Next() {
-		var logLevel, message string
-		var createdAt time.Time
-		var details sql.NullString // 处理 details 可能为 NULL 的情况
-
-		if err := rows.Scan(&logLevel, &message, &details, &createdAt); err != nil {
-			sc.logger.Error("扫描日志行失败", "error", err)
-			continue
-		}
-
+	for _, log := range rows {
 		logItem := map[string]interface{}{
-			"level":      logLevel,
-			"message":    message,
-			"created_at": createdAt,
+			"level":      log.Level,
+			"message":    log.Message,
+			"created_at": log.CreatedAt,
 		}
 
-		if details.Valid && details.String != "" {
+		if log.Details != "" {
 			var detailsObj map[string]interface{}
-			if json.Unmarshal([]byte(details.String), &detailsObj) == nil {
+			if json.Unmarshal([]byte(log.Details), &detailsObj) == nil {
 				logItem["details"] = detailsObj
 			} else {
-				logItem["details"] = details.String // 如果不是json，则作为原始字符串
+				logItem["details"] = log.Details // 如果不是json，则作为原始字符串
 			}
 		} else {
 			logItem["details"] = nil
@@ -229,6 +203,7 @@ func (sc *SystemController) CreateBackup(c *gin.Context) {
 	// 3. 保存到指定位置
 
 	sc.logger.Info("创建备份", "name", backupName)
+	setAuditTarget(c, backupName)
 	c.JSON(200, gin.H{
 		"message": "备份创建成功",
 		"name":    backupName,
@@ -272,6 +247,7 @@ func (sc *SystemController) RestoreBackup(c *gin.Context) {
 	// 4. 重启服务
 
 	sc.logger.Info("恢复备份", "name", req.BackupName)
+	setAuditTarget(c, req.BackupName)
 	c.JSON(200, gin.H{
 		"message": "备份恢复成功",
 		"name":    req.BackupName,
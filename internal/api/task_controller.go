@@ -1,29 +1,36 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/crawler"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
 	"example.com/m/v2/internal/utils"
+	"example.com/m/v2/pkg/constants"
 )
 
 // TaskController 任务控制器
 type TaskController struct {
-	db     *sql.DB
+	repos  *repository.Repositories
 	logger utils.Logger
 	config *config.Config
+	runner *runner.Runner
 }
 
 // NewTaskController 创建任务控制器
-func NewTaskController(db *sql.DB, logger utils.Logger, cfg *config.Config) *TaskController {
+func NewTaskController(repos *repository.Repositories, logger utils.Logger, cfg *config.Config, taskRunner *runner.Runner) *TaskController {
 	return &TaskController{
-		db:     db,
+		repos:  repos,
 		logger: logger,
 		config: cfg,
+		runner: taskRunner,
 	}
 }
 
@@ -35,125 +42,30 @@ type TaskRequest struct {
 }
 
 // TaskResponse 任务响应结构
-type TaskResponse struct {
-	ID            int                    `json:"id"`
-	Name          string                 `json:"name"`
-	SiteID        int                    `json:"site_id"`
-	SiteName      string                 `json:"site_name"`
-	Status        string                 `json:"status"`
-	Config        map[string]interface{} `json:"config"`
-	StartTime     *time.Time             `json:"start_time"`
-	EndTime       *time.Time             `json:"end_time"`
-	TotalURLs     int                    `json:"total_urls"`
-	ProcessedURLs int                    `json:"processed_urls"`
-	SuccessURLs   int                    `json:"success_urls"`
-	FailedURLs    int                    `json:"failed_urls"`
-	ItemsCount    int                    `json:"items_count"`
-	ErrorMessage  string                 `json:"error_message"`
-	CreatedAt     time.Time              `json:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at"`
-	Duration      int64                  `json:"duration"` // 持续时间（秒）
-	Progress      float64                `json:"progress"` // 进度百分比
-}
+type TaskResponse = repository.Task
 
 // ListTasks 获取任务列表
 func (tc *TaskController) ListTasks(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 	status := c.Query("status")
-	siteID := c.Query("site_id")
-
-	offset := (page - 1) * pageSize
-
-	// 构建查询条件
-	where := "1=1"
-	args := []interface{}{}
-
-	if status != "" {
-		where += " AND t.status = ?"
-		args = append(args, status)
-	}
-	if siteID != "" {
-		where += " AND t.site_id = ?"
-		args = append(args, siteID)
-	}
-
-	// 查询总数
-	var total int
-	countQuery := "SELECT COUNT(*) FROM tasks t WHERE " + where
-	err := tc.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		tc.logger.Error("查询任务总数失败", "error", err)
-		c.JSON(500, gin.H{"error": "查询失败"})
+	siteID, _ := strconv.Atoi(c.Query("site_id"))
+	siteIDs, ok := limitSites(c, siteID)
+	if !ok {
 		return
 	}
 
-	// 查询任务列表
-	query := `
-		SELECT t.id, t.name, t.site_id, s.name as site_name, t.status, t.config,
-		       t.start_time, t.end_time, t.total_urls, t.processed_urls, 
-		       t.success_urls, t.failed_urls, t.items_count, t.error_message,
-		       t.created_at, t.updated_at
-		FROM tasks t
-		LEFT JOIN sites s ON t.site_id = s.id
-		WHERE ` + where + `
-		ORDER BY t.created_at DESC 
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, pageSize, offset)
-
-	rows, err := tc.db.Query(query, args...)
+	tasks, total, err := tc.repos.Tasks.List(repository.TaskFilter{
+		Status:     status,
+		SiteID:     siteID,
+		SiteIDs:    siteIDs,
+		Pagination: repository.Pagination{Page: page, PageSize: pageSize},
+	})
 	if err != nil {
 		tc.logger.Error("查询任务列表失败", "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-	defer rows.Close()
-
-	var tasks []TaskResponse
-	for rows.Next() {
-		var task TaskResponse
-		var configJSON string
-		var startTime, endTime sql.NullTime
-
-		err := rows.Scan(
-			&task.ID, &task.Name, &task.SiteID, &task.SiteName, &task.Status,
-			&configJSON, &startTime, &endTime, &task.TotalURLs,
-			&task.ProcessedURLs, &task.SuccessURLs, &task.FailedURLs,
-			&task.ItemsCount, &task.ErrorMessage, &task.CreatedAt, &task.UpdatedAt,
-		)
-		if err != nil {
-			tc.logger.Error("扫描任务数据失败", "error", err)
-			continue
-		}
-
-		// 解析JSON字段
-		if configJSON != "" {
-			json.Unmarshal([]byte(configJSON), &task.Config)
-		}
-
-		if startTime.Valid {
-			task.StartTime = &startTime.Time
-		}
-		if endTime.Valid {
-			task.EndTime = &endTime.Time
-		}
-
-		// 计算持续时间和进度
-		if task.StartTime != nil {
-			if task.EndTime != nil {
-				task.Duration = int64(task.EndTime.Sub(*task.StartTime).Seconds())
-			} else {
-				task.Duration = int64(time.Since(*task.StartTime).Seconds())
-			}
-		}
-
-		if task.TotalURLs > 0 {
-			task.Progress = float64(task.ProcessedURLs) / float64(task.TotalURLs) * 100
-		}
-
-		tasks = append(tasks, task)
-	}
 
 	c.JSON(200, gin.H{
 		"data": tasks,
@@ -175,30 +87,22 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 	}
 
 	// 检查站点是否存在
-	var count int
-	err := tc.db.QueryRow("SELECT COUNT(*) FROM sites WHERE id = ? AND enabled = TRUE", req.SiteID).Scan(&count)
-	if err != nil || count == 0 {
+	site, err := tc.repos.Sites.Get(req.SiteID)
+	if err != nil || !site.Enabled {
 		c.JSON(400, gin.H{"error": "站点不存在或已禁用"})
 		return
 	}
 
-	// 序列化配置
-	configJSON, _ := json.Marshal(req.Config)
-
-	// 插入任务
-	query := `
-		INSERT INTO tasks (name, site_id, config, status, created_at, updated_at)
-		VALUES (?, ?, ?, 'pending', NOW(), NOW())
-	`
-	result, err := tc.db.Exec(query, req.Name, req.SiteID, configJSON)
-	if err != nil {
+	task := &repository.Task{Name: req.Name, SiteID: req.SiteID, Config: req.Config}
+	if err := tc.repos.Tasks.Create(task); err != nil {
 		tc.logger.Error("创建任务失败", "error", err)
 		c.JSON(500, gin.H{"error": "创建失败"})
 		return
 	}
+	id := task.ID
 
-	id, _ := result.LastInsertId()
 	tc.logger.Info("创建任务成功", "id", id, "name", req.Name)
+	setAuditTarget(c, id)
 
 	c.JSON(201, gin.H{
 		"message": "任务创建成功",
@@ -214,61 +118,11 @@ func (tc *TaskController) GetTask(c *gin.Context) {
 		return
 	}
 
-	query := `
-		SELECT t.id, t.name, t.site_id, s.name as site_name, t.status, t.config,
-		       t.start_time, t.end_time, t.total_urls, t.processed_urls, 
-		       t.success_urls, t.failed_urls, t.items_count, t.error_message,
-		       t.created_at, t.updated_at
-		FROM tasks t
-		LEFT JOIN sites s ON t.site_id = s.id
-		WHERE t.id = ?
-	`
-
-	var task TaskResponse
-	var configJSON string
-	var startTime, endTime sql.NullTime
-
-	err = tc.db.QueryRow(query, id).Scan(
-		&task.ID, &task.Name, &task.SiteID, &task.SiteName, &task.Status,
-		&configJSON, &startTime, &endTime, &task.TotalURLs,
-		&task.ProcessedURLs, &task.SuccessURLs, &task.FailedURLs,
-		&task.ItemsCount, &task.ErrorMessage, &task.CreatedAt, &task.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
-	}
-	if err != nil {
-		tc.logger.Error("查询任务详情失败", "error", err)
-		c.JSON(500, gin.H{"error": "查询失败"})
+	task, ok := tc.getTask(c, id)
+	if !ok {
 		return
 	}
 
-	// 解析JSON字段
-	if configJSON != "" {
-		json.Unmarshal([]byte(configJSON), &task.Config)
-	}
-
-	if startTime.Valid {
-		task.StartTime = &startTime.Time
-	}
-	if endTime.Valid {
-		task.EndTime = &endTime.Time
-	}
-
-	// 计算持续时间和进度
-	if task.StartTime != nil {
-		if task.EndTime != nil {
-			task.Duration = int64(task.EndTime.Sub(*task.StartTime).Seconds())
-		} else {
-			task.Duration = int64(time.Since(*task.StartTime).Seconds())
-		}
-	}
-
-	if task.TotalURLs > 0 {
-		task.Progress = float64(task.ProcessedURLs) / float64(task.TotalURLs) * 100
-	}
-
 	c.JSON(200, gin.H{"data": task})
 }
 
@@ -287,28 +141,20 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 	}
 
 	// 检查任务是否存在且未运行
-	var status string
-	err = tc.db.QueryRow("SELECT status FROM tasks WHERE id = ?", id).Scan(&status)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "任务不存在"})
+	task, ok := tc.getTask(c, id)
+	if !ok {
 		return
 	}
-	if status == "running" {
+	if task.Status == constants.SpiderStatusRunning {
 		c.JSON(400, gin.H{"error": "运行中的任务无法修改"})
 		return
 	}
 
-	// 序列化配置
-	configJSON, _ := json.Marshal(req.Config)
-
 	// 更新任务
-	query := `
-		UPDATE tasks 
-		SET name=?, site_id=?, config=?, updated_at=NOW()
-		WHERE id=?
-	`
-	_, err = tc.db.Exec(query, req.Name, req.SiteID, configJSON, id)
-	if err != nil {
+	task.Name = req.Name
+	task.SiteID = req.SiteID
+	task.Config = req.Config
+	if err := tc.repos.Tasks.Update(task); err != nil {
 		tc.logger.Error("更新任务失败", "error", err)
 		c.JSON(500, gin.H{"error": "更新失败"})
 		return
@@ -327,20 +173,17 @@ func (tc *TaskController) DeleteTask(c *gin.Context) {
 	}
 
 	// 检查任务是否存在且未运行
-	var status string
-	err = tc.db.QueryRow("SELECT status FROM tasks WHERE id = ?", id).Scan(&status)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "任务不存在"})
+	task, ok := tc.getTask(c, id)
+	if !ok {
 		return
 	}
-	if status == "running" {
+	if task.Status == constants.SpiderStatusRunning {
 		c.JSON(400, gin.H{"error": "运行中的任务无法删除"})
 		return
 	}
 
 	// 删除任务
-	_, err = tc.db.Exec("DELETE FROM tasks WHERE id = ?", id)
-	if err != nil {
+	if err := tc.repos.Tasks.Delete(id); err != nil {
 		tc.logger.Error("删除任务失败", "error", err)
 		c.JSON(500, gin.H{"error": "删除失败"})
 		return
@@ -358,29 +201,24 @@ func (tc *TaskController) StartTask(c *gin.Context) {
 		return
 	}
 
-	// 检查任务状态
-	var status string
-	err = tc.db.QueryRow("SELECT status FROM tasks WHERE id = ?", id).Scan(&status)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
-	}
-	if status == "running" {
-		c.JSON(400, gin.H{"error": "任务已在运行中"})
+	if _, ok := tc.getTask(c, id); !ok {
 		return
 	}
 
-	// 更新任务状态
-	_, err = tc.db.Exec("UPDATE tasks SET status='running', start_time=NOW(), updated_at=NOW() WHERE id=?", id)
-	if err != nil {
-		tc.logger.Error("启动任务失败", "error", err)
+	err = tc.runner.Start(id)
+	switch {
+	case errors.Is(err, runner.ErrTaskNotFound):
+		c.JSON(404, gin.H{"error": "任务不存在"})
+		return
+	case errors.Is(err, runner.ErrTaskRunning), errors.Is(err, runner.ErrSiteDisabled):
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		tc.logger.Error("启动任务失败", "id", id, "error", err)
 		c.JSON(500, gin.H{"error": "启动失败"})
 		return
 	}
 
-	// TODO: 实际启动爬虫逻辑
-	// 这里应该异步启动爬虫任务
-
 	tc.logger.Info("启动任务成功", "id", id)
 	c.JSON(200, gin.H{"message": "任务启动成功"})
 }
@@ -393,28 +231,22 @@ func (tc *TaskController) StopTask(c *gin.Context) {
 		return
 	}
 
-	// 检查任务状态
-	var status string
-	err = tc.db.QueryRow("SELECT status FROM tasks WHERE id = ?", id).Scan(&status)
-	if err == sql.ErrNoRows {
-		c.JSON(404, gin.H{"error": "任务不存在"})
+	// 检查任务是否存在
+	if _, ok := tc.getTask(c, id); !ok {
 		return
 	}
-	if status != "running" {
+
+	err = tc.runner.Stop(id)
+	if errors.Is(err, runner.ErrTaskNotRunning) {
 		c.JSON(400, gin.H{"error": "任务未在运行中"})
 		return
 	}
-
-	// 更新任务状态
-	_, err = tc.db.Exec("UPDATE tasks SET status='stopped', end_time=NOW(), updated_at=NOW() WHERE id=?", id)
 	if err != nil {
-		tc.logger.Error("停止任务失败", "error", err)
+		tc.logger.Error("停止任务失败", "id", id, "error", err)
 		c.JSON(500, gin.H{"error": "停止失败"})
 		return
 	}
 
-	// TODO: 实际停止爬虫逻辑
-
 	tc.logger.Info("停止任务成功", "id", id)
 	c.JSON(200, gin.H{"message": "任务停止成功"})
 }
@@ -427,60 +259,40 @@ func (tc *TaskController) GetTaskLogs(c *gin.Context) {
 		return
 	}
 
+	// 限定站点的API密钥只能查看所属站点的任务日志
+	if restrictedSites(c) != nil {
+		if _, ok := tc.getTask(c, id); !ok {
+			return
+		}
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
 	level := c.Query("level")
 
-	offset := (page - 1) * pageSize
-
-	// 构建查询条件
-	where := "task_id = ?"
-	args := []interface{}{id}
-
-	if level != "" {
-		where += " AND level = ?"
-		args = append(args, level)
-	}
-
-	// 查询日志
-	query := `
-		SELECT id, level, message, details, created_at
-		FROM task_logs 
-		WHERE ` + where + `
-		ORDER BY created_at DESC 
-		LIMIT ? OFFSET ?
-	`
-	args = append(args, pageSize, offset)
-
-	rows, err := tc.db.Query(query, args...)
+	rows, err := tc.repos.Logs.List(repository.LogFilter{
+		TaskID:     id,
+		Level:      level,
+		Pagination: repository.Pagination{Page: page, PageSize: pageSize},
+	})
 	if err != nil {
 		tc.logger.Error("查询任务日志失败", "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
 		return
 	}
-	defer rows.Close()
 
 	var logs []map[string]interface{}
-	for rows.Next() {
-		var logID int
-		var logLevel, message, details string
-		var createdAt time.Time
-
-		err := rows.Scan(&logID, &logLevel, &message, &details, &createdAt)
-		if err != nil {
-			continue
-		}
-
+	for _, log := range rows {
 		logItem := map[string]interface{}{
-			"id":         logID,
-			"level":      logLevel,
-			"message":    message,
-			"created_at": createdAt,
+			"id":         log.ID,
+			"level":      log.Level,
+			"message":    log.Message,
+			"created_at": log.CreatedAt,
 		}
 
-		if details != "" {
+		if log.Details != "" {
 			var detailsObj map[string]interface{}
-			json.Unmarshal([]byte(details), &detailsObj)
+			json.Unmarshal([]byte(log.Details), &detailsObj)
 			logItem["details"] = detailsObj
 		}
 
@@ -498,37 +310,151 @@ func (tc *TaskController) GetTaskStatus(c *gin.Context) {
 		return
 	}
 
-	query := `
-		SELECT status, total_urls, processed_urls, success_urls, failed_urls, items_count
-		FROM tasks WHERE id = ?
-	`
+	task, ok := tc.getTask(c, id)
+	if !ok {
+		return
+	}
 
-	var status string
-	var totalURLs, processedURLs, successURLs, failedURLs, itemsCount int
+	c.JSON(200, gin.H{
+		"status":         task.Status,
+		"total_urls":     task.TotalURLs,
+		"processed_urls": task.ProcessedURLs,
+		"success_urls":   task.SuccessURLs,
+		"failed_urls":    task.FailedURLs,
+		"skipped_urls":   task.SkippedURLs,
+		"items_count":    task.ItemsCount,
+		"progress":       task.Progress,
+	})
+}
 
-	err = tc.db.QueryRow(query, id).Scan(&status, &totalURLs, &processedURLs, &successURLs, &failedURLs, &itemsCount)
-	if err == sql.ErrNoRows {
+// getTask 查询任务并检查当前请求能否访问其站点，失败时写入错误响应并返回 false
+func (tc *TaskController) getTask(c *gin.Context, id int) (*TaskResponse, bool) {
+	task, err := tc.repos.Tasks.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(404, gin.H{"error": "任务不存在"})
-		return
+		return nil, false
 	}
 	if err != nil {
-		tc.logger.Error("查询任务状态失败", "error", err)
+		tc.logger.Error("查询任务详情失败", "id", id, "error", err)
 		c.JSON(500, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	if !siteAllowed(c, task.SiteID) {
+		return nil, false
+	}
+	return task, true
+}
+
+// streamHeartbeatInterval SSE 心跳间隔，避免代理断开空闲连接
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamTask 以 SSE 推送单个任务的进度和状态变化，任务结束后关闭连接
+func (tc *TaskController) StreamTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的任务ID"})
 		return
 	}
 
-	progress := 0.0
-	if totalURLs > 0 {
-		progress = float64(processedURLs) / float64(totalURLs) * 100
+	// 先订阅再读取当前状态，避免遗漏两者之间的状态变化
+	events, unsubscribe := tc.runner.Events().Subscribe(id)
+	defer unsubscribe()
+
+	task, ok := tc.getTask(c, id)
+	if !ok {
+		return
+	}
+	stats := crawler.Stats{
+		TotalURLs:     task.TotalURLs,
+		ProcessedURLs: task.ProcessedURLs,
+		SuccessURLs:   task.SuccessURLs,
+		FailedURLs:    task.FailedURLs,
+		SkippedURLs:   task.SkippedURLs,
+		ItemsCount:    task.ItemsCount,
 	}
 
-	c.JSON(200, gin.H{
-		"status":         status,
-		"total_urls":     totalURLs,
-		"processed_urls": processedURLs,
-		"success_urls":   successURLs,
-		"failed_urls":    failedURLs,
-		"items_count":    itemsCount,
-		"progress":       progress,
+	tc.startStream(c)
+	c.SSEvent(runner.EventStatus, runner.Event{
+		Type: runner.EventStatus, TaskID: id, SiteID: task.SiteID, Status: task.Status, Stats: &stats, Time: time.Now(),
 	})
-} 
\ No newline at end of file
+	c.Writer.Flush()
+
+	// 任务未在运行时只返回当前状态
+	if task.Status != constants.SpiderStatusRunning {
+		return
+	}
+
+	finished := func(ev runner.Event) bool {
+		return ev.Type == runner.EventStatus && ev.Status != constants.SpiderStatusRunning
+	}
+
+	// 读取状态后任务可能已经结束。执行器发布结束事件后才移除任务，且订阅先于读取状态，
+	// 此时结束事件已在缓冲中，推送缓冲中的事件后关闭连接
+	if !tc.runner.IsRunning(id) {
+		for {
+			select {
+			case ev := <-events:
+				c.SSEvent(ev.Type, ev)
+				c.Writer.Flush()
+				if finished(ev) {
+					return
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	tc.pumpEvents(c, events, finished)
+}
+
+// StreamTaskEvents 以 SSE 推送所有任务的状态变化
+func (tc *TaskController) StreamTaskEvents(c *gin.Context) {
+	if restrictedSites(c) != nil {
+		c.JSON(403, gin.H{"error": "限定站点的API密钥只能订阅单个任务"})
+		return
+	}
+
+	events, unsubscribe := tc.runner.Events().Subscribe(0)
+	defer unsubscribe()
+
+	tc.startStream(c)
+	c.Writer.Flush()
+
+	tc.pumpEvents(c, events, nil)
+}
+
+// startStream 设置 SSE 响应头，并取消服务器写超时以保持长连接
+func (tc *TaskController) startStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		tc.logger.Warn("取消写超时失败", "error", err)
+	}
+}
+
+// pumpEvents 持续推送事件直到客户端断开，done 返回 true 时推送该事件后结束
+func (tc *TaskController) pumpEvents(c *gin.Context, events <-chan runner.Event, done func(runner.Event) bool) {
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev := <-events:
+			c.SSEvent(ev.Type, ev)
+			c.Writer.Flush()
+			if done != nil && done(ev) {
+				return
+			}
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/pkg/constants"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTaskLifecycle(t *testing.T) {
+	s := newTestServer(t, false)
+	siteA := s.createSite("站点A", nil)
+	siteB := s.createSite("站点B", nil)
+
+	s.expect(400, "POST", "/api/v1/tasks", gin.H{"name": "任务", "site_id": 999}, nil)
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	decodeBody(t, s.expect(201, "POST", "/api/v1/tasks", gin.H{"name": "任务A", "site_id": siteA}, nil), &created)
+	s.expect(201, "POST", "/api/v1/tasks", gin.H{"name": "任务B", "site_id": siteB}, nil)
+
+	var list struct {
+		Data []repository.Task `json:"data"`
+	}
+	decodeBody(t, s.expect(200, "GET", path("/tasks?site_id=%d", siteA), nil, nil), &list)
+	if len(list.Data) != 1 || list.Data[0].ID != created.ID || list.Data[0].SiteName != "站点A" {
+		t.Fatalf("按站点过滤的任务列表不符: %+v", list.Data)
+	}
+
+	s.expect(200, "PUT", path("/tasks/%d", created.ID), gin.H{"name": "任务A2", "site_id": siteA, "config": gin.H{"max_pages": 5}}, nil)
+	var got struct {
+		Data repository.Task `json:"data"`
+	}
+	decodeBody(t, s.expect(200, "GET", path("/tasks/%d", created.ID), nil, nil), &got)
+	if got.Data.Name != "任务A2" || got.Data.Status != constants.SpiderStatusPending || got.Data.Config["max_pages"] != float64(5) {
+		t.Fatalf("任务未更新: %+v", got.Data)
+	}
+
+	s.expect(200, "DELETE", path("/tasks/%d", created.ID), nil, nil)
+	s.expect(404, "GET", path("/tasks/%d", created.ID), nil, nil)
+	s.expect(404, "DELETE", path("/tasks/%d", created.ID), nil, nil)
+}
+
+func TestStreamTaskEndsWithFinalStatus(t *testing.T) {
+	s := newTestServer(t, false)
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		fmt.Fprint(w, "<html><head><title>首页</title></head><body>内容</body></html>")
+	}))
+	defer page.Close()
+
+	site := &repository.Site{Name: "站点", BaseURL: page.URL, StartURLs: []string{page.URL + "/"}, Enabled: true}
+	if err := s.repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+	var created struct {
+		ID int `json:"id"`
+	}
+	decodeBody(t, s.expect(201, "POST", "/api/v1/tasks", gin.H{"name": "任务", "site_id": site.ID}, nil), &created)
+
+	srv := httptest.NewServer(s.router)
+	defer srv.Close()
+
+	// 未运行的任务只返回当前状态
+	resp, err := http.Get(srv.URL + path("/tasks/%d/stream", created.ID))
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Count(string(body), "event:status") != 1 || !strings.Contains(string(body), constants.SpiderStatusPending) {
+		t.Fatalf("未运行任务的推送内容不符: %s", body)
+	}
+
+	s.expect(200, "POST", path("/tasks/%d/start", created.ID), nil, nil)
+	resp, err = http.Get(srv.URL + path("/tasks/%d/stream", created.ID))
+	if err != nil {
+		t.Fatalf("订阅失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 任务结束后服务端关闭连接，最后一个事件为结束状态
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("读取推送失败: %v", err)
+	}
+	events := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+	last := events[len(events)-1]
+	if !strings.HasPrefix(last, "event:status") || !strings.Contains(last, `"status":"completed"`) {
+		t.Fatalf("最后一个事件不是结束状态: %s", body)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/crawler"
+	"example.com/m/v2/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly/v2"
+)
+
+// maxExtractHTMLSize 提取工具接受的最大HTML大小
+const maxExtractHTMLSize = 5 << 20
+
+// maxExtractBodySize 提取请求体的最大大小，为 JSON 转义和其他字段留出余量
+const maxExtractBodySize = 2*maxExtractHTMLSize + 64<<10
+
+// ToolsController 工具控制器
+type ToolsController struct {
+	logger utils.Logger
+	config *config.Config
+}
+
+// NewToolsController 创建工具控制器
+func NewToolsController(logger utils.Logger, cfg *config.Config) *ToolsController {
+	return &ToolsController{
+		logger: logger,
+		config: cfg,
+	}
+}
+
+// ExtractRequest 选择器提取请求结构
+type ExtractRequest struct {
+	URL       string            `json:"url"`  // 抓取的页面；同时提供 html 时仅用于补全相对链接
+	HTML      string            `json:"html"` // 原始HTML，提供时不抓取页面
+	Selectors map[string]string `json:"selectors"`
+}
+
+// Extract 按选择器提取页面数据，同时返回 DefaultParser 的通用提取结果，不写入数据。
+// 按 url 抓取时只允许访问公网地址
+func (tc *ToolsController) Extract(c *gin.Context) {
+	// 读取请求体前限制大小，避免超大请求占用内存
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxExtractBodySize)
+
+	var req ExtractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "HTML内容过大"})
+			return
+		}
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.URL == "" && req.HTML == "" {
+		c.JSON(400, gin.H{"error": "url 和 html 至少提供一个"})
+		return
+	}
+	if len(req.HTML) > maxExtractHTMLSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "HTML内容过大"})
+		return
+	}
+
+	spider := crawler.NewSpider(tc.config, nil, tc.logger)
+
+	var resp *colly.Response
+	var err error
+	if req.HTML != "" {
+		pageURL := req.URL
+		if pageURL == "" {
+			pageURL = "http://localhost/"
+		}
+		resp, err = crawler.NewHTMLResponse([]byte(req.HTML), pageURL)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		if err := crawler.CheckPublicURL(c.Request.Context(), req.URL); err != nil {
+			tc.logger.Warn("拒绝抓取页面", "url", req.URL, "ip", c.ClientIP(), "error", err)
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		resp, err = spider.FetchPublic(c.Request.Context(), req.URL)
+		if errors.Is(err, crawler.ErrPrivateAddress) {
+			tc.logger.Warn("拒绝抓取内网地址", "url", req.URL, "ip", c.ClientIP())
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			tc.logger.Error("抓取页面失败", "url", req.URL, "error", err)
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := spider.ExtractResponse(resp, req.Selectors)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := result.ParseFallback(resp); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, result)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExtractTool(t *testing.T) {
+	s := newTestServer(t, false)
+
+	var result struct {
+		Items   []struct{ Title string } `json:"items"`
+		Matches map[string]int           `json:"matches"`
+		Links   []string                 `json:"links"`
+	}
+	decodeBody(t, s.expect(200, "POST", "/api/v1/tools/extract", gin.H{
+		"url":       "https://example.com/list/",
+		"html":      `<html><body><h1>标题</h1><a href="a.html">a</a><a href="/b">b</a></body></html>`,
+		"selectors": gin.H{"title": "h1"},
+	}, nil), &result)
+	if len(result.Items) != 1 || result.Items[0].Title != "标题" || result.Matches["title"] != 1 {
+		t.Fatalf("提取结果不符: %+v", result)
+	}
+	if strings.Join(result.Links, ",") != "https://example.com/list/a.html,https://example.com/b" {
+		t.Fatalf("链接未按页面URL补全: %v", result.Links)
+	}
+
+	s.expect(400, "POST", "/api/v1/tools/extract", gin.H{"selectors": gin.H{"title": "h1"}}, nil)
+	s.expect(400, "POST", "/api/v1/tools/extract", gin.H{"url": "http://127.0.0.1:8080/"}, nil)
+	s.expect(400, "POST", "/api/v1/tools/extract", gin.H{"url": "http://169.254.169.254/latest/meta-data/"}, nil)
+
+	// 超出大小限制与参数错误区分开
+	s.expect(413, "POST", "/api/v1/tools/extract", gin.H{"html": strings.Repeat("a", maxExtractHTMLSize+1)}, nil)
+	s.expect(413, "POST", "/api/v1/tools/extract", gin.H{"html": strings.Repeat("<", maxExtractBodySize/6+1)}, nil)
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/auth"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// UserController 用户和角色管理控制器
+type UserController struct {
+	repos  *repository.Repositories
+	logger utils.Logger
+}
+
+// NewUserController 创建用户管理控制器
+func NewUserController(repos *repository.Repositories, logger utils.Logger) *UserController {
+	return &UserController{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// CreateUserRequest 创建用户请求结构
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+// UpdateUserRequest 更新用户请求结构，未提供的字段保持不变
+type UpdateUserRequest struct {
+	Password *string `json:"password"`
+	Role     *string `json:"role"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+// ListUsers 获取用户列表
+func (uc *UserController) ListUsers(c *gin.Context) {
+	users, err := uc.repos.Users.List()
+	if err != nil {
+		uc.logger.Error("查询用户列表失败", "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return
+	}
+	if users == nil {
+		users = []repository.User{}
+	}
+	c.JSON(200, gin.H{"users": users})
+}
+
+// GetUser 获取用户详情
+func (uc *UserController) GetUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	user, ok := uc.getUser(c, id)
+	if !ok {
+		return
+	}
+	c.JSON(200, gin.H{"user": user})
+}
+
+// CreateUser 创建用户，未指定角色时为 viewer
+func (uc *UserController) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		c.JSON(400, gin.H{"error": "用户名不能为空"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = auth.RoleViewer
+	}
+	if !auth.ValidRole(req.Role) {
+		c.JSON(400, gin.H{"error": "未知的角色: " + req.Role})
+		return
+	}
+
+	_, err := uc.repos.Users.GetByUsername(req.Username)
+	if err == nil {
+		c.JSON(400, gin.H{"error": "用户名已存在"})
+		return
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		uc.logger.Error("查询用户失败", "username", req.Username, "error", err)
+		c.JSON(500, gin.H{"error": "创建失败"})
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := &repository.User{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         req.Role,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+	if err := uc.repos.Users.Create(user); err != nil {
+		uc.logger.Error("创建用户失败", "username", req.Username, "error", err)
+		c.JSON(500, gin.H{"error": "创建失败"})
+		return
+	}
+
+	uc.logger.Info("创建用户", "username", user.Username, "role", user.Role, "operator", operatorName(c))
+	setAuditTarget(c, user.ID)
+	c.JSON(201, gin.H{"user": user})
+}
+
+// UpdateUser 修改用户的角色、启用状态或重置密码。
+// 修改密码或禁用用户时注销其全部登录会话；不允许移除最后一个启用的管理员
+func (uc *UserController) UpdateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := uc.getUser(c, id)
+	if !ok {
+		return
+	}
+	wasAdmin := user.Role == auth.RoleAdmin && user.Enabled
+
+	revoke := false
+	if req.Role != nil {
+		if !auth.ValidRole(*req.Role) {
+			c.JSON(400, gin.H{"error": "未知的角色: " + *req.Role})
+			return
+		}
+		user.Role = *req.Role
+	}
+	if req.Enabled != nil {
+		revoke = revoke || (user.Enabled && !*req.Enabled)
+		user.Enabled = *req.Enabled
+	}
+	if req.Password != nil {
+		hash, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		user.PasswordHash = hash
+		revoke = true
+		// 密码哈希不参与审计比较，单独记录
+		auditDetail(c, "password_changed", true)
+	}
+
+	// 至少保留一个启用的管理员
+	if wasAdmin && (user.Role != auth.RoleAdmin || !user.Enabled) {
+		admins, err := uc.countActiveAdmins()
+		if err != nil {
+			uc.logger.Error("查询管理员数量失败", "error", err)
+			c.JSON(500, gin.H{"error": "更新失败"})
+			return
+		}
+		if admins <= 1 {
+			c.JSON(400, gin.H{"error": "不能移除最后一个启用的管理员"})
+			return
+		}
+	}
+
+	if err := uc.repos.Users.Update(user); err != nil {
+		uc.logger.Error("更新用户失败", "id", id, "error", err)
+		c.JSON(500, gin.H{"error": "更新失败"})
+		return
+	}
+	if revoke {
+		if err := uc.repos.Sessions.RevokeUser(user.ID, time.Now()); err != nil {
+			uc.logger.Error("注销用户会话失败", "id", id, "error", err)
+		}
+	}
+
+	uc.logger.Info("更新用户", "username", user.Username, "role", user.Role, "enabled", user.Enabled,
+		"operator", operatorName(c))
+	c.JSON(200, gin.H{"user": user})
+}
+
+// getUser 查询用户，不存在或出错时写入响应并返回 false
+func (uc *UserController) getUser(c *gin.Context, id int) (*repository.User, bool) {
+	user, err := uc.repos.Users.Get(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "用户不存在"})
+		return nil, false
+	}
+	if err != nil {
+		uc.logger.Error("查询用户失败", "id", id, "error", err)
+		c.JSON(500, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	return user, true
+}
+
+// countActiveAdmins 统计启用的管理员数量
+func (uc *UserController) countActiveAdmins() (int, error) {
+	users, err := uc.repos.Users.List()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, user := range users {
+		if user.Role == auth.RoleAdmin && user.Enabled {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// operatorName 返回当前登录用户的用户名，用于日志
+func operatorName(c *gin.Context) string {
+	if user := currentUser(c); user != nil {
+		return user.Username
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// APIKeyPrefix API密钥的固定前缀，便于在日志和配置中识别
+const APIKeyPrefix = "ck_"
+
+// apiKeyDisplayLength 保存和展示的密钥前缀长度
+const apiKeyDisplayLength = 11
+
+// API密钥授权范围
+const (
+	ScopeDataRead     = "data:read"     // 只读访问数据，包括列表、详情、搜索和导出
+	ScopeTasksTrigger = "tasks:trigger" // 运行站点任务，启动、停止任务并查看任务状态
+)
+
+// Scope API密钥授权范围及其对应的权限
+type Scope struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// scopes 可授予API密钥的范围，API密钥不能获得管理类权限
+var scopes = []Scope{
+	{ScopeDataRead, "只读访问数据：列表、详情、搜索、导出和统计", []Permission{PermDataRead}},
+	{ScopeTasksTrigger, "触发任务：运行站点任务，启动、停止任务并查看任务状态", []Permission{PermTasksRead, PermTasksOperate}},
+}
+
+// Scopes 返回全部授权范围
+func Scopes() []Scope {
+	return scopes
+}
+
+// ValidScope 判断是否为已知的授权范围
+func ValidScope(name string) bool {
+	for _, scope := range scopes {
+		if scope.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsOfScopes 返回授权范围对应的全部权限
+func PermissionsOfScopes(granted []string) []Permission {
+	perms := []Permission{}
+	for _, scope := range scopes {
+		for _, name := range granted {
+			if scope.Name == name {
+				perms = append(perms, scope.Permissions...)
+				break
+			}
+		}
+	}
+	return perms
+}
+
+// ScopesAllow 判断授权范围是否包含指定权限
+func ScopesAllow(granted []string, perm Permission) bool {
+	for _, p := range PermissionsOfScopes(granted) {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAPIKey 生成随机的API密钥，返回完整密钥和用于展示的前缀。完整密钥只在创建时返回一次
+func NewAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:apiKeyDisplayLength], nil
+}
+
+// HashAPIKey 计算API密钥的 SHA-256 哈希。密钥为高熵随机值，无需使用 bcrypt
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
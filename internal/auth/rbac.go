@@ -0,0 +1,107 @@
+package auth
+
+// Permission 接口权限，格式为 <资源>:<操作>
+type Permission string
+
+// 接口权限
+const (
+	PermSitesRead    Permission = "sites:read"    // 查看站点和调度
+	PermSitesWrite   Permission = "sites:write"   // 创建、修改、删除站点，测试选择器，设置调度
+	PermSitesOperate Permission = "sites:operate" // 启用/禁用站点
+	PermTasksRead    Permission = "tasks:read"    // 查看任务、日志和进度
+	PermTasksWrite   Permission = "tasks:write"   // 创建、修改、删除任务
+	PermTasksOperate Permission = "tasks:operate" // 启动、停止任务，运行站点任务
+	PermDataRead     Permission = "data:read"     // 查看、搜索、导出数据
+	PermDataWrite    Permission = "data:write"    // 删除、导入数据
+	PermToolsUse     Permission = "tools:use"     // 使用选择器调试工具
+	PermSystemRead   Permission = "system:read"   // 查看系统状态和日志
+	PermSystemAdmin  Permission = "system:admin"  // 修改系统配置，备份和恢复
+	PermUsersManage  Permission = "users:manage"  // 管理用户和角色
+)
+
+// 角色，后一个角色包含前一个角色的全部权限
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleEditor   = "editor"
+	RoleAdmin    = "admin"
+)
+
+// Role 角色及其权限
+type Role struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// roles 内置角色，按权限从少到多排列
+var roles = buildRoles([]Role{
+	{RoleViewer, "只读：查看站点、任务、数据和系统状态", []Permission{
+		PermSitesRead, PermTasksRead, PermDataRead, PermSystemRead,
+	}},
+	{RoleOperator, "运维：在只读基础上启动、停止任务，启用、禁用和运行站点", []Permission{
+		PermSitesOperate, PermTasksOperate,
+	}},
+	{RoleEditor, "编辑：在运维基础上管理站点、选择器、任务和数据", []Permission{
+		PermSitesWrite, PermTasksWrite, PermDataWrite, PermToolsUse,
+	}},
+	{RoleAdmin, "管理员：全部权限，包括系统配置、备份恢复和用户管理", []Permission{
+		PermSystemAdmin, PermUsersManage,
+	}},
+})
+
+// buildRoles 将每个角色新增的权限与前一个角色的权限合并
+func buildRoles(defs []Role) []Role {
+	var inherited []Permission
+	result := make([]Role, len(defs))
+	for i, role := range defs {
+		inherited = append(inherited, role.Permissions...)
+		role.Permissions = append([]Permission(nil), inherited...)
+		result[i] = role
+	}
+	return result
+}
+
+// Roles 返回全部内置角色
+func Roles() []Role {
+	return roles
+}
+
+// ValidRole 判断是否为内置角色
+func ValidRole(name string) bool {
+	_, ok := findRole(name)
+	return ok
+}
+
+// PermissionsOf 返回角色拥有的权限，未知角色返回空列表
+func PermissionsOf(role string) []Permission {
+	r, ok := findRole(role)
+	if !ok {
+		return []Permission{}
+	}
+	return r.Permissions
+}
+
+// HasPermission 判断角色是否拥有指定权限，未知角色没有任何权限
+func HasPermission(role string, perm Permission) bool {
+	r, ok := findRole(role)
+	if !ok {
+		return false
+	}
+	for _, p := range r.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// findRole 按名称查找角色
+func findRole(name string) (Role, bool) {
+	for _, role := range roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return Role{}, false
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 令牌类型，访问令牌用于调用接口，刷新令牌只用于换取新的访问令牌
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// tokenIssuer 签发的令牌中的 iss
+const tokenIssuer = "colly-crawler"
+
+// minSecretLength JWT 密钥的最小字节数，HS256 的密钥不应短于 256 位
+const minSecretLength = 32
+
+// placeholderSecret 示例配置中的密钥，不能直接用于签发令牌
+const placeholderSecret = "your-secret-key"
+
+var (
+	// ErrInvalidToken 令牌格式、签名或类型不正确
+	ErrInvalidToken = errors.New("令牌无效")
+	// ErrTokenExpired 令牌已过期
+	ErrTokenExpired = errors.New("令牌已过期")
+)
+
+// Claims 令牌携带的用户信息，RegisteredClaims.ID 为登录会话ID
+type Claims struct {
+	UserID   int    `json:"uid"`
+	Username string `json:"username"`
+	Type     string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// SessionID 返回令牌所属的登录会话ID
+func (c *Claims) SessionID() string {
+	return c.ID
+}
+
+// TokenPair 登录或刷新后返回的令牌
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Manager 签发和校验 JWT，使用 HS256 签名
+type Manager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewManager 按认证配置创建令牌管理器，TokenExpire 和 RefreshExpire 单位为小时。
+// 密钥为空、仍为示例值或短于 minSecretLength 时返回错误
+func NewManager(cfg config.AuthConfig) (*Manager, error) {
+	if cfg.JWTSecret == "" {
+		return nil, errors.New("未配置 JWT 密钥 (web.auth.jwt_secret)")
+	}
+	if cfg.JWTSecret == placeholderSecret {
+		return nil, errors.New("JWT 密钥 (web.auth.jwt_secret) 仍为示例值，请修改为随机字符串")
+	}
+	if len(cfg.JWTSecret) < minSecretLength {
+		return nil, fmt.Errorf("JWT 密钥 (web.auth.jwt_secret) 至少需要 %d 个字符", minSecretLength)
+	}
+	if cfg.TokenExpire <= 0 || cfg.RefreshExpire <= 0 {
+		return nil, fmt.Errorf("令牌有效期必须大于 0: token_expire=%d, refresh_expire=%d", cfg.TokenExpire, cfg.RefreshExpire)
+	}
+
+	return &Manager{
+		secret:     []byte(cfg.JWTSecret),
+		accessTTL:  time.Duration(cfg.TokenExpire) * time.Hour,
+		refreshTTL: time.Duration(cfg.RefreshExpire) * time.Hour,
+	}, nil
+}
+
+// RefreshTTL 刷新令牌的有效期，即登录会话的有效期
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// Issue 为登录会话签发访问令牌和刷新令牌。访问令牌不会晚于会话过期，
+// 刷新令牌的过期时间即会话的过期时间
+func (m *Manager) Issue(userID int, username, sessionID string, sessionExpires time.Time) (*TokenPair, error) {
+	now := time.Now()
+	accessExpires := now.Add(m.accessTTL)
+	if accessExpires.After(sessionExpires) {
+		accessExpires = sessionExpires
+	}
+
+	access, err := m.sign(userID, username, sessionID, TokenTypeAccess, now, accessExpires)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := m.sign(userID, username, sessionID, TokenTypeRefresh, now, sessionExpires)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExpires,
+		RefreshExpiresAt: sessionExpires,
+	}, nil
+}
+
+// sign 签发一个令牌
+func (m *Manager) sign(userID int, username, sessionID, typ string, issuedAt, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Type:     typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Issuer:    tokenIssuer,
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", fmt.Errorf("签发令牌失败: %w", err)
+	}
+	return token, nil
+}
+
+// Parse 校验令牌的签名、有效期和类型，返回令牌携带的信息
+func (m *Manager) Parse(tokenString, typ string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil || claims.Type != typ || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// NewSessionID 生成随机的登录会话ID
+func NewSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成会话ID失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
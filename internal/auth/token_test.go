@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret-0123456789abcdefghijklmn"
+
+func newTestManager(t *testing.T, secret string) *Manager {
+	t.Helper()
+	m, err := NewManager(config.AuthConfig{JWTSecret: secret, TokenExpire: 1, RefreshExpire: 24})
+	if err != nil {
+		t.Fatalf("创建令牌管理器失败: %v", err)
+	}
+	return m
+}
+
+func TestNewManager(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AuthConfig
+		ok   bool
+	}{
+		{"有效配置", config.AuthConfig{JWTSecret: testSecret, TokenExpire: 1, RefreshExpire: 24}, true},
+		{"未配置密钥", config.AuthConfig{TokenExpire: 1, RefreshExpire: 24}, false},
+		{"示例密钥", config.AuthConfig{JWTSecret: "your-secret-key", TokenExpire: 1, RefreshExpire: 24}, false},
+		{"密钥过短", config.AuthConfig{JWTSecret: strings.Repeat("x", minSecretLength-1), TokenExpire: 1, RefreshExpire: 24}, false},
+		{"最短密钥", config.AuthConfig{JWTSecret: strings.Repeat("x", minSecretLength), TokenExpire: 1, RefreshExpire: 24}, true},
+		{"有效期为0", config.AuthConfig{JWTSecret: testSecret, RefreshExpire: 24}, false},
+	}
+	for _, tt := range tests {
+		_, err := NewManager(tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestManagerParse(t *testing.T) {
+	m := newTestManager(t, testSecret)
+	now := time.Now()
+	pair, err := m.Issue(7, "alice", "session-1", now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+
+	expired, err := m.sign(7, "alice", "session-1", TokenTypeAccess, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	noSession, _ := m.sign(7, "alice", "", TokenTypeAccess, now, now.Add(time.Hour))
+	other, _ := newTestManager(t, strings.Repeat("o", minSecretLength)).sign(7, "alice", "session-1", TokenTypeAccess, now, now.Add(time.Hour))
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{
+		UserID: 7, Type: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{ID: "session-1", Issuer: tokenIssuer, ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+		typ   string
+		err   error
+	}{
+		{"访问令牌", pair.AccessToken, TokenTypeAccess, nil},
+		{"刷新令牌", pair.RefreshToken, TokenTypeRefresh, nil},
+		{"访问令牌不能用于刷新", pair.AccessToken, TokenTypeRefresh, ErrInvalidToken},
+		{"刷新令牌不能用于访问", pair.RefreshToken, TokenTypeAccess, ErrInvalidToken},
+		{"签名被篡改", pair.AccessToken[:len(pair.AccessToken)-2] + "xx", TokenTypeAccess, ErrInvalidToken},
+		{"其他密钥签发", other, TokenTypeAccess, ErrInvalidToken},
+		{"未签名", unsigned, TokenTypeAccess, ErrInvalidToken},
+		{"没有会话ID", noSession, TokenTypeAccess, ErrInvalidToken},
+		{"已过期", expired, TokenTypeAccess, ErrTokenExpired},
+		{"格式错误", "invalid", TokenTypeAccess, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		claims, err := m.Parse(tt.token, tt.typ)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v，期望 %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && (claims.UserID != 7 || claims.Username != "alice" || claims.SessionID() != "session-1") {
+			t.Errorf("%s: claims = %+v", tt.name, claims)
+		}
+	}
+}
+
+func TestIssueCapsAccessTokenAtSession(t *testing.T) {
+	m := newTestManager(t, testSecret)
+	sessionExpires := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	pair, err := m.Issue(1, "bob", "session-2", sessionExpires)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	if !pair.ExpiresAt.Equal(sessionExpires) || !pair.RefreshExpiresAt.Equal(sessionExpires) {
+		t.Fatalf("expires_at=%v refresh_expires_at=%v，期望均为 %v", pair.ExpiresAt, pair.RefreshExpiresAt, sessionExpires)
+	}
+}
@@ -10,10 +10,12 @@ import (
 
 // Config 主配置结构
 type Config struct {
-	Spider  SpiderConfig  `yaml:"spider"`
-	Storage StorageConfig `yaml:"storage"`
-	Logging LoggingConfig `yaml:"logging"`
-	Web     WebConfig     `yaml:"web"`
+	Spider      SpiderConfig      `yaml:"spider"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Web         WebConfig         `yaml:"web"`
+	Performance PerformanceConfig `yaml:"performance"`
+	Filters     FiltersConfig     `yaml:"filters"`
 }
 
 // SpiderConfig 爬虫配置
@@ -24,13 +26,22 @@ type SpiderConfig struct {
 	Timeout    int    `yaml:"timeout"`    // 超时时间（秒）
 	Retries    int    `yaml:"retries"`    // 重试次数
 	ProxyURL   string `yaml:"proxy_url"`  // 代理地址
+	MaxDepth   int    `yaml:"max_depth"`  // 最大链接深度，0 表示不跟进链接
+	MaxPages   int    `yaml:"max_pages"`  // 最大页面数，0 表示不限制
+
+	RespectRobots bool `yaml:"respect_robots"` // 是否遵守robots.txt
+
+	URLPatterns      []string `yaml:"url_patterns"`      // URL包含规则（正则）
+	ExcludePatterns  []string `yaml:"exclude_patterns"`  // URL排除规则（正则）
+	ForbiddenDomains []string `yaml:"forbidden_domains"` // 禁止的域名
 }
 
 // StorageConfig 存储配置
 type StorageConfig struct {
-	Type      string   `yaml:"type"`       // 存储类型: file, database, excel
-	OutputDir string   `yaml:"output_dir"` // 输出目录
-	Database  DBConfig `yaml:"database"`   // 数据库配置
+	Type       string   `yaml:"type"`        // 存储类型: database, json, csv, excel，多个类型用逗号分隔，file 等同于 database,json
+	OutputDir  string   `yaml:"output_dir"`  // 文件存储的输出目录
+	RotateRows int      `yaml:"rotate_rows"` // 文件存储单个文件的最大行数，超出后写入新文件
+	Database   DBConfig `yaml:"database"`    // 数据库配置
 }
 
 // DBConfig 数据库配置
@@ -41,6 +52,24 @@ type DBConfig struct {
 	Username string `yaml:"username"` // 用户名
 	Password string `yaml:"password"` // 密码
 	Database string `yaml:"database"` // 数据库名
+
+	SQLiteFile string `yaml:"sqlite_file"` // SQLite 数据库文件，":memory:" 表示内存数据库
+	SSLMode    string `yaml:"ssl_mode"`    // PostgreSQL SSL 模式
+}
+
+// PerformanceConfig 性能配置，控制爬取数据的异步批量写入
+type PerformanceConfig struct {
+	QueueSize     int `yaml:"queue_size"`     // 写入队列大小，队列满时爬虫等待
+	WorkerCount   int `yaml:"worker_count"`   // 写入协程数
+	BatchSize     int `yaml:"batch_size"`     // 每批写入的最大条数
+	FlushInterval int `yaml:"flush_interval"` // 未满一批时的最长等待时间（毫秒）
+}
+
+// FiltersConfig 数据过滤配置
+type FiltersConfig struct {
+	EnableDeduplication bool   `yaml:"enable_deduplication"` // 是否启用去重，内容未变化的数据不重复写入
+	DedupField          string `yaml:"dedup_field"`          // 去重字段: url, title, content
+	SimHashDistance     int    `yaml:"simhash_distance"`     // 按 content 去重时视为近似重复的 SimHash 汉明距离，0 表示只去除完全相同的内容
 }
 
 // LoggingConfig 日志配置
@@ -56,6 +85,8 @@ type WebConfig struct {
 	APIPrefix  string     `yaml:"api_prefix"`  // API前缀
 	CORS       CORSConfig `yaml:"cors"`        // CORS配置
 	Auth       AuthConfig `yaml:"auth"`        // 认证配置
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"` // 限流配置
 }
 
 // CORSConfig CORS配置
@@ -65,11 +96,27 @@ type CORSConfig struct {
 	Headers []string `yaml:"headers"` // 允许的头部
 }
 
+// RateLimitConfig 限流配置。按API密钥、登录用户或客户端IP分别计算令牌桶，
+// 导出、搜索和运行站点任务在总限额之外另有单独的限额
+type RateLimitConfig struct {
+	Enable            bool `yaml:"enable"`              // 是否启用限流
+	RequestsPerMinute int  `yaml:"requests_per_minute"` // 每个客户端每分钟请求数
+	Burst             int  `yaml:"burst"`               // 允许的突发请求数，默认等于每分钟请求数
+	ExportPerMinute   int  `yaml:"export_per_minute"`   // 导出数据每分钟请求数
+	SearchPerMinute   int  `yaml:"search_per_minute"`   // 搜索数据每分钟请求数
+	RunPerMinute      int  `yaml:"run_per_minute"`      // 运行站点任务每分钟请求数
+}
+
 // AuthConfig 认证配置
 type AuthConfig struct {
-	Enable      bool   `yaml:"enable"`       // 是否启用认证
-	JWTSecret   string `yaml:"jwt_secret"`   // JWT密钥
-	TokenExpire int    `yaml:"token_expire"` // Token过期时间（小时）
+	Enable        bool   `yaml:"enable"`         // 是否启用认证
+	JWTSecret     string `yaml:"jwt_secret"`     // JWT密钥
+	TokenExpire   int    `yaml:"token_expire"`   // Token过期时间（小时）
+	RefreshExpire int    `yaml:"refresh_expire"` // 刷新Token过期时间（小时），即登录会话的有效期
+
+	// 没有任何用户时，启动时创建的初始管理员账号。密码为空时随机生成并写入日志
+	AdminUsername string `yaml:"admin_username"`
+	AdminPassword string `yaml:"admin_password"`
 }
 
 // LoadConfig 加载主配置文件
@@ -110,11 +157,38 @@ func setDefaults(config *Config) {
 		config.Spider.Retries = 3
 	}
 	if config.Storage.Type == "" {
-		config.Storage.Type = "database" // 只保留 database 类型
+		config.Storage.Type = "database"
+	}
+	if config.Storage.Database.Driver == "" {
+		config.Storage.Database.Driver = "sqlite3"
+	}
+	if config.Storage.Database.SQLiteFile == "" {
+		config.Storage.Database.SQLiteFile = "./data/crawler.db"
+	}
+	if config.Storage.Database.SSLMode == "" {
+		config.Storage.Database.SSLMode = "disable"
 	}
 	if config.Storage.OutputDir == "" {
 		config.Storage.OutputDir = "./data/output"
 	}
+	if config.Storage.RotateRows == 0 {
+		config.Storage.RotateRows = 100000
+	}
+	if config.Performance.QueueSize == 0 {
+		config.Performance.QueueSize = 10000
+	}
+	if config.Performance.WorkerCount == 0 {
+		config.Performance.WorkerCount = 4
+	}
+	if config.Performance.BatchSize == 0 {
+		config.Performance.BatchSize = 100
+	}
+	if config.Performance.FlushInterval == 0 {
+		config.Performance.FlushInterval = 5000
+	}
+	if config.Filters.DedupField == "" {
+		config.Filters.DedupField = "url"
+	}
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -132,4 +206,25 @@ func setDefaults(config *Config) {
 	if config.Web.APIPrefix == "" {
 		config.Web.APIPrefix = "/api/v1"
 	}
+	if config.Web.Auth.TokenExpire == 0 {
+		config.Web.Auth.TokenExpire = 24
+	}
+	if config.Web.Auth.RefreshExpire == 0 {
+		config.Web.Auth.RefreshExpire = 168
+	}
+	if config.Web.Auth.AdminUsername == "" {
+		config.Web.Auth.AdminUsername = "admin"
+	}
+	if config.Web.RateLimit.RequestsPerMinute == 0 {
+		config.Web.RateLimit.RequestsPerMinute = 60
+	}
+	if config.Web.RateLimit.ExportPerMinute == 0 {
+		config.Web.RateLimit.ExportPerMinute = 5
+	}
+	if config.Web.RateLimit.SearchPerMinute == 0 {
+		config.Web.RateLimit.SearchPerMinute = 20
+	}
+	if config.Web.RateLimit.RunPerMinute == 0 {
+		config.Web.RateLimit.RunPerMinute = 5
+	}
 }
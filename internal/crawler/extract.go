@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+
+	"example.com/m/v2/pkg/models"
+)
+
+// ExtractResult 按选择器提取页面的结果，用于在启用站点前调试选择器
+type ExtractResult struct {
+	URL        string         `json:"url"`
+	StatusCode int            `json:"status_code"`
+	Items      []*models.Item `json:"items"`   // item 选择器匹配的每个元素对应一条数据
+	Matches    map[string]int `json:"matches"` // 每个选择器在页面中匹配的元素数
+	Links      []string       `json:"links"`   // 页面中发现的链接
+
+	// DefaultParser 的通用提取结果，仅在 ParseFallback 后填充
+	Fallback     *models.Item   `json:"fallback,omitempty"`
+	FallbackList []*models.Item `json:"fallback_list,omitempty"`
+}
+
+// Preview 抓取单个页面并按任务选择器提取数据，不写入存储也不跟进链接
+func (s *Spider) Preview(ctx context.Context, task *models.CrawlTask, rawURL string) (*ExtractResult, error) {
+	resp, err := s.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.ExtractResponse(resp, task.Selectors)
+}
+
+// PreviewPublic 与 Preview 相同，但通过 FetchPublic 抓取，只允许访问公网地址。
+// 用于按用户提供的URL试运行选择器
+func (s *Spider) PreviewPublic(ctx context.Context, task *models.CrawlTask, rawURL string) (*ExtractResult, error) {
+	resp, err := s.FetchPublic(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.ExtractResponse(resp, task.Selectors)
+}
+
+// Fetch 抓取单个HTML页面
+func (s *Spider) Fetch(ctx context.Context, rawURL string) (*colly.Response, error) {
+	return s.fetch(ctx, rawURL, http.DefaultTransport, false)
+}
+
+// FetchPublic 抓取单个HTML页面，只允许连接公网地址，重定向后的地址同样检查。
+// 用于按用户提供的URL抓取页面，避免借此访问内网服务；调用前可用 CheckPublicURL 预先检查
+func (s *Spider) FetchPublic(ctx context.Context, rawURL string) (*colly.Response, error) {
+	return s.fetch(ctx, rawURL, publicTransport, true)
+}
+
+// fetch 使用指定的传输层抓取单个HTML页面，publicOnly 时检查每次重定向的目标
+func (s *Spider) fetch(ctx context.Context, rawURL string, transport http.RoundTripper, publicOnly bool) (*colly.Response, error) {
+	c := colly.NewCollector()
+	c.UserAgent = s.config.Spider.UserAgent
+	c.SetRequestTimeout(time.Duration(s.config.Spider.Timeout) * time.Second)
+	c.WithTransport(&contextTransport{ctx: ctx, base: transport})
+	c.IgnoreRobotsTxt = true
+	if publicOnly {
+		c.SetRedirectHandler(func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("重定向次数过多")
+			}
+			return CheckPublicURL(req.Context(), req.URL.String())
+		})
+	}
+
+	var resp *colly.Response
+	c.OnResponse(func(r *colly.Response) {
+		resp = r
+	})
+
+	if err := c.Visit(rawURL); err != nil {
+		return nil, fmt.Errorf("抓取页面失败: %w", err)
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("抓取页面失败: 未收到响应")
+	}
+	if contentType := resp.Headers.Get("Content-Type"); !strings.Contains(strings.ToLower(contentType), "html") {
+		return nil, fmt.Errorf("页面不是HTML: %s", contentType)
+	}
+
+	return resp, nil
+}
+
+// NewHTMLResponse 将原始HTML包装为响应，pageURL 用于补全相对链接
+func NewHTMLResponse(body []byte, pageURL string) (*colly.Response, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的页面URL: %w", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/html; charset=utf-8")
+	return &colly.Response{
+		StatusCode: http.StatusOK,
+		Body:       body,
+		Request:    &colly.Request{URL: u, Headers: &http.Header{}},
+		Headers:    &headers,
+	}, nil
+}
+
+// ExtractResponse 按选择器提取响应中的数据，提取方式与爬取时相同
+func (s *Spider) ExtractResponse(resp *colly.Response, selectors map[string]string) (*ExtractResult, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败: %w", err)
+	}
+
+	pageURL := resp.Request.URL.String()
+	result := &ExtractResult{
+		URL:        pageURL,
+		StatusCode: resp.StatusCode,
+		Items:      []*models.Item{},
+		Matches:    make(map[string]int),
+		Links:      []string{},
+	}
+
+	for name, sel := range selectors {
+		if sel != "" {
+			result.Matches[name] = doc.Find(sel).Length()
+		}
+	}
+
+	// 与 processPage 相同，item 选择器匹配的每个元素提取一条数据
+	itemSelector := "html"
+	if sel, ok := selectors["item"]; ok && sel != "" {
+		itemSelector = sel
+	}
+	eachElement(resp, doc.Selection, itemSelector, func(e *colly.HTMLElement) {
+		item := &models.Item{
+			URL:       pageURL,
+			Timestamp: time.Now(),
+			Source:    getDomainFromURL(pageURL),
+		}
+		s.extractData(e, item, selectors)
+		result.Items = append(result.Items, item)
+	})
+
+	// 与链接跟进相同，未配置 links 选择器时使用页面中的全部链接
+	linkSelector := "a[href]"
+	if sel, ok := selectors["links"]; ok && sel != "" {
+		linkSelector = sel
+	}
+	seen := make(map[string]bool)
+	eachElement(resp, doc.Selection, linkSelector, func(e *colly.HTMLElement) {
+		link := e.Request.AbsoluteURL(e.Attr("href"))
+		if link != "" && !seen[link] {
+			seen[link] = true
+			result.Links = append(result.Links, link)
+		}
+	})
+
+	return result, nil
+}
+
+// eachElement 对匹配选择器的每个节点构造 HTMLElement 并回调，与 Collector 的 OnHTML 一致
+func eachElement(resp *colly.Response, root *goquery.Selection, selector string, fn func(e *colly.HTMLElement)) {
+	i := 0
+	root.Find(selector).Each(func(_ int, sel *goquery.Selection) {
+		for _, n := range sel.Nodes {
+			fn(colly.NewHTMLElementFromSelectionNode(resp, sel, n, i))
+			i++
+		}
+	})
+}
+
+// ParseFallback 使用 DefaultParser 提取页面，作为不配置选择器时的参考结果
+func (r *ExtractResult) ParseFallback(resp *colly.Response) error {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(resp.Body))
+	if err != nil {
+		return fmt.Errorf("解析HTML失败: %w", err)
+	}
+
+	parser := NewDefaultParser()
+	var parseErr error
+	eachElement(resp, doc.Selection, "html", func(e *colly.HTMLElement) {
+		if r.Fallback != nil {
+			return
+		}
+		if r.Fallback, parseErr = parser.Parse(e); parseErr != nil {
+			return
+		}
+		r.FallbackList, parseErr = parser.ParseList(e)
+	})
+	return parseErr
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URL 被过滤的原因
+const (
+	SkipReasonForbiddenDomain = "forbidden_domain" // 命中禁止的域名
+	SkipReasonExcluded        = "excluded_pattern" // 命中排除规则
+	SkipReasonNotMatched      = "not_matched"      // 未匹配任何包含规则
+	SkipReasonInvalidURL      = "invalid_url"      // URL 无法解析
+	SkipReasonRobots          = "robots_txt"       // robots.txt 禁止访问
+)
+
+// URLFilter URL 过滤器，在访问前按禁止域名和包含/排除正则筛选URL
+type URLFilter struct {
+	allow            []*regexp.Regexp
+	deny             []*regexp.Regexp
+	forbiddenDomains []string
+}
+
+// NewURLFilter 创建URL过滤器
+func NewURLFilter(allowPatterns, denyPatterns, forbiddenDomains []string) (*URLFilter, error) {
+	f := &URLFilter{}
+
+	for _, pattern := range allowPatterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re != nil {
+			f.allow = append(f.allow, re)
+		}
+	}
+
+	for _, pattern := range denyPatterns {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re != nil {
+			f.deny = append(f.deny, re)
+		}
+	}
+
+	for _, domain := range forbiddenDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			f.forbiddenDomains = append(f.forbiddenDomains, domain)
+		}
+	}
+
+	return f, nil
+}
+
+// Check 检查URL是否允许访问，不允许时返回原因。
+// 起始URL由用户显式配置，只检查禁止域名和排除规则，不要求匹配包含规则。
+func (f *URLFilter) Check(rawURL string, isStart bool) (bool, string) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false, SkipReasonInvalidURL
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	for _, domain := range f.forbiddenDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return false, SkipReasonForbiddenDomain
+		}
+	}
+
+	for _, re := range f.deny {
+		if re.MatchString(rawURL) {
+			return false, SkipReasonExcluded
+		}
+	}
+
+	if isStart || len(f.allow) == 0 {
+		return true, ""
+	}
+
+	for _, re := range f.allow {
+		if re.MatchString(rawURL) {
+			return true, ""
+		}
+	}
+
+	return false, SkipReasonNotMatched
+}
+
+// compilePattern 编译URL正则，空字符串忽略
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的URL规则 %q: %w", pattern, err)
+	}
+	return re, nil
+}
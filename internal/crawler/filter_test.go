@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/m/v2/pkg/models"
+)
+
+func TestURLFilterCheck(t *testing.T) {
+	filter, err := NewURLFilter(
+		[]string{`/news/\d+$`, " ", `/blog/`},
+		[]string{`\.pdf$`, `/news/0+$`},
+		[]string{"Ads.Example.com", ""},
+	)
+	if err != nil {
+		t.Fatalf("创建过滤器失败: %v", err)
+	}
+
+	tests := []struct {
+		url     string
+		isStart bool
+		ok      bool
+		reason  string
+	}{
+		{"https://example.com/news/42", false, true, ""},
+		{"https://example.com/blog/post", false, true, ""},
+		{"https://example.com/about", false, false, SkipReasonNotMatched},
+		{"https://example.com/about", true, true, ""}, // 起始URL不要求匹配包含规则
+		{"https://example.com/news/000", false, false, SkipReasonExcluded},
+		{"https://example.com/blog/a.pdf", true, false, SkipReasonExcluded},
+		{"https://ads.example.com/news/1", false, false, SkipReasonForbiddenDomain},
+		{"https://cdn.ads.example.com/news/1", true, false, SkipReasonForbiddenDomain},
+		{"https://badads.example.com/news/1", false, true, ""}, // 只匹配完整的域名层级
+		{"https://example.com/%zz", false, false, SkipReasonInvalidURL},
+	}
+	for _, tt := range tests {
+		ok, reason := filter.Check(tt.url, tt.isStart)
+		if ok != tt.ok || reason != tt.reason {
+			t.Errorf("Check(%q, %v) = %v, %q，期望 %v, %q", tt.url, tt.isStart, ok, reason, tt.ok, tt.reason)
+		}
+	}
+}
+
+func TestURLFilterNoRules(t *testing.T) {
+	filter, err := NewURLFilter(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("创建过滤器失败: %v", err)
+	}
+	if ok, reason := filter.Check("https://example.com/any", false); !ok {
+		t.Fatalf("没有规则时应允许所有URL，原因 %q", reason)
+	}
+}
+
+func TestURLFilterInvalidPattern(t *testing.T) {
+	if _, err := NewURLFilter([]string{"("}, nil, nil); err == nil {
+		t.Fatal("无效的包含规则应返回错误")
+	}
+	if _, err := NewURLFilter(nil, []string{"[a-"}, nil); err == nil {
+		t.Fatal("无效的排除规则应返回错误")
+	}
+}
+
+func TestCrawlSkipsFilteredLinks(t *testing.T) {
+	site := newTestSite(t, map[string][]string{
+		"/":           {"/news/1", "/news/2", "/about", "/news/3.pdf"},
+		"/news/1":     nil,
+		"/news/2":     nil,
+		"/about":      nil,
+		"/news/3.pdf": nil,
+	}, "")
+	spider := newTestSpider(t)
+	crawl(t, spider, site, models.CrawlTaskRules{
+		MaxDepth:        1,
+		URLPatterns:     []string{`/news/`},
+		ExcludePatterns: []string{`\.pdf$`},
+	})
+
+	visited := site.visited()
+	if strings.Join(visited, ",") != "/,/news/1,/news/2" {
+		t.Fatalf("访问的页面 %v", visited)
+	}
+	if stats := spider.Stats(); stats.SkippedURLs != 2 {
+		t.Fatalf("跳过 %d 个URL，期望 2 个", stats.SkippedURLs)
+	}
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// robotsFetchTimeout 获取 robots.txt 的超时时间
+const robotsFetchTimeout = 10 * time.Second
+
+// RobotsChecker robots.txt 检查器，按主机获取并缓存 robots.txt
+type RobotsChecker struct {
+	userAgent string
+	client    *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*robotsEntry
+}
+
+// robotsEntry 一个主机的 robots.txt 缓存项，done 关闭后 group 可读
+type robotsEntry struct {
+	done  chan struct{}
+	group *robotstxt.Group
+}
+
+// NewRobotsChecker 创建 robots.txt 检查器，按 userAgent 匹配规则组
+func NewRobotsChecker(ctx context.Context, userAgent string) *RobotsChecker {
+	return &RobotsChecker{
+		userAgent: userAgent,
+		client: &http.Client{
+			Timeout:   robotsFetchTimeout,
+			Transport: &contextTransport{ctx: ctx, base: http.DefaultTransport},
+		},
+		cache: make(map[string]*robotsEntry),
+	}
+}
+
+// Allowed 检查URL是否被 robots.txt 允许访问
+func (rc *RobotsChecker) Allowed(u *url.URL) bool {
+	group := rc.group(u)
+	if group == nil {
+		return true
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return group.Test(path)
+}
+
+// CrawlDelay 返回 robots.txt 为该主机声明的抓取间隔，未声明时为 0
+func (rc *RobotsChecker) CrawlDelay(u *url.URL) time.Duration {
+	group := rc.group(u)
+	if group == nil {
+		return 0
+	}
+	return group.CrawlDelay
+}
+
+// group 获取主机对应的规则组，首次访问时下载并缓存 robots.txt。
+// 下载时不持有锁，同一主机的并发请求等待同一次下载，不影响其他主机。
+// 下载失败时视为没有限制，同样缓存结果避免重复请求。
+func (rc *RobotsChecker) group(u *url.URL) *robotstxt.Group {
+	key := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	entry, ok := rc.cache[key]
+	if !ok {
+		entry = &robotsEntry{done: make(chan struct{})}
+		rc.cache[key] = entry
+	}
+	rc.mu.Unlock()
+
+	if ok {
+		<-entry.done
+		return entry.group
+	}
+
+	entry.group = rc.fetch(key)
+	close(entry.done)
+	return entry.group
+}
+
+// fetch 下载主机的 robots.txt 并查找匹配 userAgent 的规则组，失败时返回 nil
+func (rc *RobotsChecker) fetch(key string) *robotstxt.Group {
+	req, err := http.NewRequest(http.MethodGet, key+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	// 部分站点按 User-Agent 返回不同的规则
+	req.Header.Set("User-Agent", rc.userAgent)
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	robots, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil
+	}
+	return robots.FindGroup(rc.userAgent)
+}
@@ -0,0 +1,173 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"example.com/m/v2/pkg/models"
+)
+
+const testRobots = `User-agent: other-bot
+Disallow: /
+
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Crawl-delay: 2
+`
+
+func TestRobotsChecker(t *testing.T) {
+	var fetches atomic.Int32
+	var userAgent atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		userAgent.Store(r.Header.Get("User-Agent"))
+		fmt.Fprint(w, testRobots)
+	}))
+	defer srv.Close()
+
+	rc := NewRobotsChecker(context.Background(), "test-agent")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/news/1?page=2", true},
+		{"/private", false},
+		{"/private/data", false},
+		{"/private/open/a", true},
+	}
+
+	// 并发检查同一主机只下载一次 robots.txt
+	var wg sync.WaitGroup
+	for _, tt := range tests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, _ := url.Parse(srv.URL + tt.path)
+			if got := rc.Allowed(u); got != tt.want {
+				t.Errorf("Allowed(%q) = %v，期望 %v", tt.path, got, tt.want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	u, _ := url.Parse(srv.URL + "/")
+	if delay := rc.CrawlDelay(u); delay != 2*time.Second {
+		t.Errorf("CrawlDelay = %v，期望 2s", delay)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("robots.txt 下载了 %d 次，期望 1 次", n)
+	}
+	if ua := userAgent.Load(); ua != "test-agent" {
+		t.Errorf("请求 robots.txt 的 User-Agent 为 %q", ua)
+	}
+
+	// 规则组按 User-Agent 匹配
+	other := NewRobotsChecker(context.Background(), "other-bot")
+	if other.Allowed(u) {
+		t.Error("other-bot 应被禁止访问全站")
+	}
+}
+
+func TestRobotsCheckerUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	rc := NewRobotsChecker(context.Background(), "test-agent")
+	u, _ := url.Parse(srv.URL + "/private")
+	if !rc.Allowed(u) || rc.CrawlDelay(u) != 0 {
+		t.Fatal("没有 robots.txt 时应不受限制")
+	}
+
+	// 下载失败时视为没有限制
+	closed, _ := url.Parse("http://127.0.0.1:1/private")
+	if !rc.Allowed(closed) {
+		t.Fatal("robots.txt 下载失败时应允许访问")
+	}
+}
+
+func TestCrawlRespectsRobots(t *testing.T) {
+	site := newTestSite(t, map[string][]string{
+		"/":          {"/public", "/private/a"},
+		"/public":    nil,
+		"/private/a": nil,
+	}, "User-agent: *\nDisallow: /private\n")
+
+	respect := true
+	spider := newTestSpider(t)
+	crawl(t, spider, site, models.CrawlTaskRules{MaxDepth: 1, RespectRobots: &respect})
+
+	if visited := strings.Join(site.visited(), ","); visited != "/,/public" {
+		t.Fatalf("访问的页面 %s", visited)
+	}
+	if stats := spider.Stats(); stats.SkippedURLs != 1 {
+		t.Fatalf("跳过 %d 个URL，期望 1 个", stats.SkippedURLs)
+	}
+}
+
+func TestStopWhileFetchingRobots(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			close(requested)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	respect := true
+	spider := newTestSpider(t)
+	done := make(chan error, 1)
+	go func() {
+		done <- spider.StartWithTask(context.Background(), &models.CrawlTask{
+			Name:      "test",
+			BaseURL:   srv.URL,
+			StartURLs: []string{srv.URL + "/"},
+			Rules:     models.CrawlTaskRules{RespectRobots: &respect},
+		})
+	}()
+
+	<-requested
+	// 下载 robots.txt 时不持有爬虫的锁，Stop 立即返回并中止下载
+	stopped := make(chan struct{})
+	go func() {
+		spider.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("下载 robots.txt 期间 Stop 被阻塞")
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("爬取应被取消，实际返回 %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop 后爬取未结束")
+	}
+}
@@ -1,9 +1,13 @@
 package crawler
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocolly/colly/v2"
@@ -23,8 +27,33 @@ type Spider struct {
 	logger  utils.Logger
 
 	collector *colly.Collector
+	writer    *storage.BufferedStorage // 当前任务的异步写入器，未配置存储时为 nil
+	robots    *RobotsChecker           // 未启用 robots.txt 时为 nil
+	cancel    context.CancelFunc
 	running   bool
 	mu        sync.RWMutex
+
+	// 爬取计数，爬取过程中由回调并发更新
+	totalURLs   atomic.Int64
+	successURLs atomic.Int64
+	failedURLs  atomic.Int64
+	skippedURLs atomic.Int64
+	itemsCount  atomic.Int64
+	currentURL  atomic.Value // 最近一次请求的URL
+
+	// 已被过滤的URL，避免同一链接重复计数
+	skipped sync.Map
+}
+
+// Stats 爬取统计快照
+type Stats struct {
+	TotalURLs     int    `json:"total_urls"`
+	ProcessedURLs int    `json:"processed_urls"`
+	SuccessURLs   int    `json:"success_urls"`
+	FailedURLs    int    `json:"failed_urls"`
+	SkippedURLs   int    `json:"skipped_urls"`
+	ItemsCount    int    `json:"items_count"`
+	CurrentURL    string `json:"current_url"`
 }
 
 // NewSpider 创建新的爬虫实例
@@ -37,16 +66,38 @@ func NewSpider(cfg *config.Config, store storage.Storage, logger utils.Logger) *
 	}
 }
 
-// StartWithTask 启动针对单个任务的爬虫
-func (s *Spider) StartWithTask(task *models.CrawlTask) error {
+// StartWithTask 启动针对单个任务的爬虫，ctx 取消或调用 Stop 后中止爬取
+func (s *Spider) StartWithTask(ctx context.Context, task *models.CrawlTask) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if s.running {
+		s.mu.Unlock()
 		return fmt.Errorf("爬虫已在运行中")
 	}
 
 	s.logger.Info("初始化爬虫任务...", "site", task.Name)
+	s.resetStats()
+
+	filter, err := s.newURLFilter(task)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 数据经写入队列异步批量保存，任务结束前写完队列中的数据
+	s.writer = nil
+	if s.storage != nil {
+		writer := s.newWriter()
+		s.writer = writer
+		defer writer.Stop()
+	}
+
+	s.robots = nil
+	if s.respectRobots(task) {
+		s.robots = NewRobotsChecker(ctx, s.config.Spider.UserAgent)
+	}
 
 	// 创建 Collector
 	c := colly.NewCollector(
@@ -55,10 +106,23 @@ func (s *Spider) StartWithTask(task *models.CrawlTask) error {
 	)
 
 	// 配置 Collector
-	s.setupCollector(c, task)
+	s.setupCollector(ctx, c, task)
 
 	// 设置请求处理
-	s.setupHandlers(c, task)
+	s.setupHandlers(ctx, c, task, filter)
+
+	robots := s.robots
+	s.collector = c
+	s.cancel = cancel
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.cancel = nil
+		s.mu.Unlock()
+	}()
 
 	// 限制并发和延迟
 	concurrent := s.config.Spider.Concurrent
@@ -70,31 +134,45 @@ func (s *Spider) StartWithTask(task *models.CrawlTask) error {
 		delay = task.Rules.Delay
 	}
 
+	// robots.txt 声明的 Crawl-delay 大于配置间隔时，为该主机单独设置限速规则。
+	// 下载 robots.txt 可能较慢，在释放锁之后进行，期间仍可调用 Stop 中止。
+	// Collector 按添加顺序匹配规则，主机规则需在通配规则之前添加。
+	if robots != nil {
+		s.applyCrawlDelay(c, robots, task.StartURLs, time.Duration(delay)*time.Millisecond)
+	}
+
 	c.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: concurrent,
 		Delay:       time.Duration(delay) * time.Millisecond,
 	})
 
-	s.collector = c
-	s.running = true
-
 	// 开始爬取
 	s.logger.Info("开始爬取站点", "site", task.Name)
 	for _, url := range task.StartURLs {
+		if ctx.Err() != nil {
+			break
+		}
+		if !s.allowURL(filter, url, true) {
+			continue
+		}
 		s.logger.Info("访问URL", "url", url)
 		c.Visit(url)
 	}
 
-	// 等待所有请求完成
+	// 等待所有请求完成（取消后排队中的请求会被直接丢弃）
 	c.Wait()
 
-	s.running = false
+	if err := ctx.Err(); err != nil {
+		s.logger.Info("站点爬取已中止", "site", task.Name)
+		return err
+	}
+
 	s.logger.Info("站点爬取完成", "site", task.Name)
 	return nil
 }
 
-// Stop 停止爬虫
+// Stop 停止爬虫，中止排队和进行中的请求
 func (s *Spider) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -104,23 +182,51 @@ func (s *Spider) Stop() error {
 	}
 
 	s.logger.Info("停止爬虫...")
-	s.running = false
-
-	if s.collector != nil {
-		s.collector.OnError(nil)
+	if s.cancel != nil {
+		s.cancel()
 	}
 
 	return nil
 }
 
+// Stats 返回当前爬取统计
+func (s *Spider) Stats() Stats {
+	success := int(s.successURLs.Load())
+	failed := int(s.failedURLs.Load())
+	currentURL, _ := s.currentURL.Load().(string)
+	return Stats{
+		TotalURLs:     int(s.totalURLs.Load()),
+		ProcessedURLs: success + failed,
+		SuccessURLs:   success,
+		FailedURLs:    failed,
+		SkippedURLs:   int(s.skippedURLs.Load()),
+		ItemsCount:    int(s.itemsCount.Load()),
+		CurrentURL:    currentURL,
+	}
+}
+
+// resetStats 清空爬取统计
+func (s *Spider) resetStats() {
+	s.totalURLs.Store(0)
+	s.successURLs.Store(0)
+	s.failedURLs.Store(0)
+	s.skippedURLs.Store(0)
+	s.itemsCount.Store(0)
+	s.currentURL.Store("")
+	s.skipped.Clear()
+}
+
 // setupCollector 配置 Collector
-func (s *Spider) setupCollector(c *colly.Collector, task *models.CrawlTask) {
+func (s *Spider) setupCollector(ctx context.Context, c *colly.Collector, task *models.CrawlTask) {
 	// 设置用户代理
 	c.UserAgent = s.config.Spider.UserAgent
 
 	// 设置超时
 	c.SetRequestTimeout(time.Duration(s.config.Spider.Timeout) * time.Second)
 
+	// 请求绑定到任务上下文，取消时中断进行中的请求
+	c.WithTransport(&contextTransport{ctx: ctx, base: http.DefaultTransport})
+
 	// 添加扩展
 	extensions.RandomUserAgent(c)
 	extensions.Referer(c)
@@ -133,20 +239,54 @@ func (s *Spider) setupCollector(c *colly.Collector, task *models.CrawlTask) {
 	// 允许重复访问
 	c.AllowURLRevisit = false
 
-	// 设置允许的域名
+	// robots.txt 由 RobotsChecker 在访问前检查
+	c.IgnoreRobotsTxt = true
+
+	// 限制链接深度，起始URL的深度为1
+	if maxDepth, _ := s.crawlLimits(task); maxDepth > 0 {
+		c.MaxDepth = maxDepth + 1
+	}
+
+	// 设置允许的域名，Collector 按不含端口的主机名匹配
 	domain := getDomainFromURL(task.BaseURL)
+	if u, err := url.Parse(task.BaseURL); err == nil && u.Hostname() != "" {
+		domain = u.Hostname()
+	}
 	c.AllowedDomains = []string{domain}
 }
 
 // setupHandlers 设置请求处理器
-func (s *Spider) setupHandlers(c *colly.Collector, task *models.CrawlTask) {
+func (s *Spider) setupHandlers(ctx context.Context, c *colly.Collector, task *models.CrawlTask, filter *URLFilter) {
+	maxDepth, maxPages := s.crawlLimits(task)
+	var scheduled atomic.Int64
+
 	// 请求前处理
 	c.OnRequest(func(r *colly.Request) {
+		// 任务已取消，丢弃排队中的请求
+		if ctx.Err() != nil {
+			r.Abort()
+			return
+		}
+
+		// 重试请求不重复计入总数
+		if r.Headers.Get("Retry-Count") == "" {
+			// 达到最大页面数后丢弃后续请求
+			if n := scheduled.Add(1); maxPages > 0 && n > int64(maxPages) {
+				if n == int64(maxPages)+1 {
+					s.logger.Info("已达到最大页面数，停止抓取新页面", "max_pages", maxPages)
+				}
+				r.Abort()
+				return
+			}
+			s.totalURLs.Add(1)
+		}
+		s.currentURL.Store(r.URL.String())
 		s.logger.Info("访问页面", "url", r.URL.String())
 	})
 
 	// 响应处理
 	c.OnResponse(func(r *colly.Response) {
+		s.successURLs.Add(1)
 		s.logger.Info("收到响应", "url", r.Request.URL.String(), "status", r.StatusCode)
 	})
 
@@ -160,8 +300,30 @@ func (s *Spider) setupHandlers(c *colly.Collector, task *models.CrawlTask) {
 		s.processPage(e, task)
 	})
 
+	// 链接跟进 - 使用 links 选择器发现链接，未配置时跟进页面中的全部链接
+	if maxDepth > 0 {
+		linkSelector := "a[href]"
+		if sel, ok := task.Selectors["links"]; ok && sel != "" {
+			linkSelector = sel
+		}
+
+		c.OnHTML(linkSelector, func(e *colly.HTMLElement) {
+			link := e.Request.AbsoluteURL(e.Attr("href"))
+			if link == "" || !s.allowURL(filter, link, false) {
+				return
+			}
+			// 超出深度、已访问或不在允许域名内的链接由 Collector 忽略
+			e.Request.Visit(link)
+		})
+	}
+
 	// 错误处理
 	c.OnError(func(r *colly.Response, err error) {
+		// 任务取消导致的失败不计入统计，也不重试
+		if ctx.Err() != nil {
+			return
+		}
+
 		s.logger.Error("请求失败", "url", r.Request.URL.String(), "error", err.Error())
 
 		// 重试逻辑
@@ -175,9 +337,17 @@ func (s *Spider) setupHandlers(c *colly.Collector, task *models.CrawlTask) {
 			r.Request.Headers.Set("Retry-Count", newCount)
 
 			s.logger.Info("重试请求", "url", r.Request.URL.String(), "retry", newCount)
-			time.Sleep(time.Second * 2) // 等待2秒后重试
-			r.Request.Retry()
+			// 等待2秒后重试
+			select {
+			case <-time.After(time.Second * 2):
+				r.Request.Retry()
+			case <-ctx.Done():
+			}
+			return
 		}
+
+		// 重试次数用尽，计为失败
+		s.failedURLs.Add(1)
 	})
 
 	// 抓取完成
@@ -186,6 +356,91 @@ func (s *Spider) setupHandlers(c *colly.Collector, task *models.CrawlTask) {
 	})
 }
 
+// crawlLimits 返回任务的最大链接深度和最大页面数，任务未设置时使用全局配置
+func (s *Spider) crawlLimits(task *models.CrawlTask) (maxDepth, maxPages int) {
+	maxDepth = s.config.Spider.MaxDepth
+	maxPages = s.config.Spider.MaxPages
+	if task.Rules.MaxDepth > 0 {
+		maxDepth = task.Rules.MaxDepth
+	}
+	if task.Rules.MaxPages > 0 {
+		maxPages = task.Rules.MaxPages
+	}
+	return maxDepth, maxPages
+}
+
+// newURLFilter 根据任务规则和全局配置创建URL过滤器。
+// 任务配置了包含规则时覆盖全局包含规则，排除规则和禁止域名两者合并。
+func (s *Spider) newURLFilter(task *models.CrawlTask) (*URLFilter, error) {
+	allow := task.Rules.URLPatterns
+	if len(allow) == 0 {
+		allow = s.config.Spider.URLPatterns
+	}
+
+	var deny, forbidden []string
+	deny = append(deny, s.config.Spider.ExcludePatterns...)
+	deny = append(deny, task.Rules.ExcludePatterns...)
+	forbidden = append(forbidden, s.config.Spider.ForbiddenDomains...)
+	forbidden = append(forbidden, task.Rules.ForbiddenDomains...)
+
+	return NewURLFilter(allow, deny, forbidden)
+}
+
+// allowURL 检查URL是否允许访问，被过滤的URL计入统计
+func (s *Spider) allowURL(filter *URLFilter, rawURL string, isStart bool) bool {
+	ok, reason := filter.Check(rawURL, isStart)
+	if ok && s.robots != nil {
+		if u, err := url.Parse(rawURL); err == nil && !s.robots.Allowed(u) {
+			ok, reason = false, SkipReasonRobots
+		}
+	}
+	if ok {
+		return true
+	}
+
+	if _, seen := s.skipped.LoadOrStore(rawURL, reason); !seen {
+		s.skippedURLs.Add(1)
+		if reason == SkipReasonRobots {
+			s.logger.Warn("robots.txt 禁止访问", "url", rawURL, "reason", reason, "user_agent", s.config.Spider.UserAgent)
+		} else {
+			s.logger.Info("跳过URL", "url", rawURL, "reason", reason)
+		}
+	}
+	return false
+}
+
+// respectRobots 判断任务是否遵守 robots.txt，任务未设置时使用全局配置
+func (s *Spider) respectRobots(task *models.CrawlTask) bool {
+	if task.Rules.RespectRobots != nil {
+		return *task.Rules.RespectRobots
+	}
+	return s.config.Spider.RespectRobots
+}
+
+// applyCrawlDelay 按 robots.txt 的 Crawl-delay 为起始URL所在主机设置限速规则，调用时不能持有 s.mu
+func (s *Spider) applyCrawlDelay(c *colly.Collector, robots *RobotsChecker, startURLs []string, delay time.Duration) {
+	seen := make(map[string]bool)
+	for _, rawURL := range startURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || seen[u.Host] {
+			continue
+		}
+		seen[u.Host] = true
+
+		crawlDelay := robots.CrawlDelay(u)
+		if crawlDelay <= delay {
+			continue
+		}
+
+		s.logger.Info("应用 robots.txt 抓取间隔", "host", u.Host, "crawl_delay", crawlDelay)
+		c.Limit(&colly.LimitRule{
+			DomainGlob:  u.Host,
+			Parallelism: 1,
+			Delay:       crawlDelay,
+		})
+	}
+}
+
 // processPage 处理页面数据
 func (s *Spider) processPage(e *colly.HTMLElement, task *models.CrawlTask) {
 	url := e.Request.URL.String()
@@ -200,14 +455,37 @@ func (s *Spider) processPage(e *colly.HTMLElement, task *models.CrawlTask) {
 	// 根据站点配置的选择器提取数据
 	s.extractData(e, item, task.Selectors)
 
-	// 保存数据
-	if err := s.storage.Save(item); err != nil {
+	// 未配置存储时只抓取不保存
+	if s.writer == nil {
+		return
+	}
+
+	// 保存数据，写入队列满时在此等待
+	if err := s.writer.Save(storage.ItemContext{SiteID: task.SiteID, TaskID: task.ID}, item); err != nil {
 		s.logger.Error("保存数据失败", "url", url, "error", err)
 	} else {
-		s.logger.Info("数据保存成功", "url", url, "title", item.Title)
+		s.logger.Info("数据已加入写入队列", "url", url, "title", item.Title)
 	}
 }
 
+// newWriter 按性能配置创建异步写入器，数据写入成功后才计入数据数
+func (s *Spider) newWriter() *storage.BufferedStorage {
+	perf := s.config.Performance
+	return storage.NewBufferedStorage(s.storage, storage.BufferOptions{
+		QueueSize:     perf.QueueSize,
+		Workers:       perf.WorkerCount,
+		BatchSize:     perf.BatchSize,
+		FlushInterval: time.Duration(perf.FlushInterval) * time.Millisecond,
+		OnSaved: func(ic storage.ItemContext, saved int) {
+			s.itemsCount.Add(int64(saved))
+			s.logger.Debug("批量保存数据", "count", saved)
+		},
+		OnError: func(ic storage.ItemContext, item *models.Item, err error) {
+			s.logger.Error("保存数据失败", "url", item.URL, "error", err)
+		},
+	})
+}
+
 // extractData 提取页面数据
 func (s *Spider) extractData(e *colly.HTMLElement, item *models.Item, selectors map[string]string) {
 	// 动态根据selectors提取数据
@@ -256,6 +534,17 @@ func (s *Spider) extractData(e *colly.HTMLElement, item *models.Item, selectors
 	}
 }
 
+// contextTransport 为每个请求附加任务上下文
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
 // 辅助函数
 func getRetryCount(retryStr string) int {
 	if retryStr == "" {
@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/storage"
+	"example.com/m/v2/pkg/models"
+)
+
+// testLogger 将日志写入测试输出，只在测试失败或 -v 时显示
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Info(msg string, kv ...interface{})  { l.t.Logf("[INFO] %s %v", msg, kv) }
+func (l testLogger) Error(msg string, kv ...interface{}) { l.t.Logf("[ERROR] %s %v", msg, kv) }
+func (l testLogger) Warn(msg string, kv ...interface{})  { l.t.Logf("[WARN] %s %v", msg, kv) }
+func (l testLogger) Debug(msg string, kv ...interface{}) { l.t.Logf("[DEBUG] %s %v", msg, kv) }
+
+// discardStorage 丢弃写入的数据，只记录条数
+type discardStorage struct {
+	mu    sync.Mutex
+	items []*models.Item
+}
+
+func (d *discardStorage) Save(ic storage.ItemContext, item *models.Item) error {
+	_, err := d.SaveBatch(ic, []*models.Item{item})
+	return err
+}
+
+func (d *discardStorage) SaveBatch(ic storage.ItemContext, items []*models.Item) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.items = append(d.items, items...)
+	return len(items), nil
+}
+
+func (d *discardStorage) Close() error { return nil }
+
+// testSite 按路径返回包含指定链接的页面，并记录被访问的路径
+type testSite struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	hits []string
+}
+
+// newTestSite 创建测试站点，pages 为各路径页面中的链接，robots 不为空时作为 robots.txt 返回
+func newTestSite(t *testing.T, pages map[string][]string, robots string) *testSite {
+	t.Helper()
+	site := &testSite{}
+	site.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, robots)
+			return
+		}
+
+		site.mu.Lock()
+		site.hits = append(site.hits, r.URL.Path)
+		site.mu.Unlock()
+
+		links, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body>", r.URL.Path)
+		for _, link := range links {
+			fmt.Fprintf(w, `<a href="%s">%s</a>`, link, link)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+// visited 返回排序后的已访问路径
+func (s *testSite) visited() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := append([]string(nil), s.hits...)
+	sort.Strings(paths)
+	return paths
+}
+
+// newTestSpider 创建数据写入 discardStorage 的爬虫
+func newTestSpider(t *testing.T) *Spider {
+	cfg := &config.Config{}
+	cfg.Spider = config.SpiderConfig{Concurrent: 2, Timeout: 5, UserAgent: "test-agent"}
+	return NewSpider(cfg, &discardStorage{}, testLogger{t})
+}
+
+// crawl 从站点首页开始执行一次爬取
+func crawl(t *testing.T, spider *Spider, site *testSite, rules models.CrawlTaskRules) {
+	t.Helper()
+	task := &models.CrawlTask{
+		Name:      "test",
+		BaseURL:   site.URL,
+		StartURLs: []string{site.URL + "/"},
+		Rules:     rules,
+	}
+	if err := spider.StartWithTask(context.Background(), task); err != nil {
+		t.Fatalf("爬取失败: %v", err)
+	}
+}
+
+func TestCrawlLimits(t *testing.T) {
+	chain := map[string][]string{"/": {"/1"}, "/1": {"/2"}, "/2": {"/3"}, "/3": {"/4"}, "/4": nil}
+	fan := map[string][]string{"/": {"/a", "/b", "/c", "/d", "/e", "/f"}}
+	for _, path := range fan["/"] {
+		fan[path] = nil
+	}
+
+	tests := []struct {
+		name  string
+		pages map[string][]string
+		rules models.CrawlTaskRules
+		want  []string // 期望访问的路径，为 nil 时只检查数量
+		count int
+	}{
+		{"不跟进链接", chain, models.CrawlTaskRules{}, []string{"/"}, 1},
+		{"深度1", chain, models.CrawlTaskRules{MaxDepth: 1}, []string{"/", "/1"}, 2},
+		{"深度3", chain, models.CrawlTaskRules{MaxDepth: 3}, []string{"/", "/1", "/2", "/3"}, 4},
+		{"不限页面数", fan, models.CrawlTaskRules{MaxDepth: 1}, nil, 7},
+		{"最大页面数", fan, models.CrawlTaskRules{MaxDepth: 1, MaxPages: 3}, nil, 3},
+		{"页面数限制深度链", chain, models.CrawlTaskRules{MaxDepth: 10, MaxPages: 2}, []string{"/", "/1"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newTestSite(t, tt.pages, "")
+			spider := newTestSpider(t)
+			crawl(t, spider, site, tt.rules)
+
+			visited := site.visited()
+			if len(visited) != tt.count {
+				t.Fatalf("访问了 %d 个页面 %v，期望 %d 个", len(visited), visited, tt.count)
+			}
+			if tt.want != nil && strings.Join(visited, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("访问的页面 %v，期望 %v", visited, tt.want)
+			}
+			if stats := spider.Stats(); stats.TotalURLs != tt.count || stats.SuccessURLs != tt.count {
+				t.Fatalf("统计不符: %+v", stats)
+			}
+		})
+	}
+}
+
+func TestCrawlWithoutStorage(t *testing.T) {
+	site := newTestSite(t, map[string][]string{"/": {"/a"}, "/a": nil}, "")
+	cfg := &config.Config{}
+	cfg.Spider = config.SpiderConfig{Concurrent: 1, Timeout: 5, UserAgent: "test-agent"}
+	crawl(t, NewSpider(cfg, nil, testLogger{t}), site, models.CrawlTaskRules{MaxDepth: 1})
+
+	if got := site.visited(); len(got) != 2 {
+		t.Fatalf("访问 %v，期望 / 和 /a", got)
+	}
+}
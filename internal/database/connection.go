@@ -3,14 +3,20 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/migrations"
 )
 
 // NewConnection 创建数据库连接
-func NewConnection(cfg config.DBConfig) (*sql.DB, error) {
+func NewConnection(cfg config.DBConfig) (*DB, error) {
 	var dsn string
 	
 	switch cfg.Driver {
@@ -22,10 +28,22 @@ func NewConnection(cfg config.DBConfig) (*sql.DB, error) {
 			cfg.Port,
 			cfg.Database,
 		)
+	case "postgres":
+		dsn = postgresDSN(cfg)
+	case "sqlite3":
+		var err error
+		if dsn, err = sqliteDSN(cfg.SQLiteFile); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
 	}
 
+	dialect, err := NewDialect(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := sql.Open(cfg.Driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库连接失败: %w", err)
@@ -35,143 +53,171 @@ func NewConnection(cfg config.DBConfig) (*sql.DB, error) {
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
+	if cfg.Driver == "sqlite3" && cfg.SQLiteFile == ":memory:" {
+		// 内存数据库在最后一个连接关闭时销毁，连接不过期
+		db.SetConnMaxLifetime(0)
+	}
 
 	// 测试连接
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("数据库连接测试失败: %w", err)
 	}
 
-	return db, nil
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// postgresDSN 生成 PostgreSQL 连接串，用户名和密码中的特殊字符会被转义
+func postgresDSN(cfg config.DBConfig) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     "/" + cfg.Database,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// sqliteDSN 生成 SQLite 连接串，开启外键约束并在写冲突时等待。
+// 内存数据库使用共享缓存，使连接池中的连接访问同一个库。
+func sqliteDSN(file string) (string, error) {
+	const params = "_foreign_keys=on&_busy_timeout=5000"
+
+	if file == "" {
+		file = "./data/crawler.db"
+	}
+	if file == ":memory:" {
+		return "file::memory:?cache=shared&" + params, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", fmt.Errorf("创建数据库目录失败: %w", err)
+	}
+	return "file:" + file + "?_journal_mode=WAL&" + params, nil
+}
+
+// Migrate 将数据库结构升级到最新版本，返回本次应用的迁移
+func Migrate(db *DB) ([]migrations.Migration, error) {
+	if err := AdoptLegacySchema(db); err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrations.NewMigrator(db.DB, db.Dialect.Driver())
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		return applied, fmt.Errorf("执行数据库迁移失败: %w", err)
+	}
+	return applied, nil
+}
+
+// AdoptLegacySchema 整理引入迁移前创建的 MySQL 数据库，使首个迁移可以在其上执行。
+// 执行迁移前调用，其他数据库或已由迁移管理的数据库不做处理
+func AdoptLegacySchema(db *DB) error {
+	if db.Dialect.Driver() != "mysql" {
+		return nil
+	}
+	return adoptLegacySchema(db.DB)
 }
 
-// InitTables 初始化数据库表
-func InitTables(db *sql.DB) error {
-	tables := []string{
-		createSitesTable,
-		createTasksTable,
-		createCrawlDataTable,
-		createTaskLogsTable,
-		createSystemConfigTable,
+// adoptLegacySchema 引入迁移前由建表语句创建的数据库，先补齐后续新增的字段，
+// 使其与首个迁移的表结构一致。已有 schema_migrations 表时不做处理。
+func adoptLegacySchema(db *sql.DB) error {
+	managed, err := tableExists(db, "schema_migrations")
+	if err != nil || managed {
+		return err
 	}
 
-	for _, table := range tables {
-		if _, err := db.Exec(table); err != nil {
-			return fmt.Errorf("创建表失败: %w", err)
+	if err := renameLegacyCrawlData(db); err != nil {
+		return err
+	}
+
+	for _, column := range legacyColumns {
+		exists, err := tableExists(db, column.table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := addColumnIfMissing(db, column.table, column.name, column.definition); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// 站点表
-const createSitesTable = `
-CREATE TABLE IF NOT EXISTS sites (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(255) NOT NULL UNIQUE COMMENT '站点名称',
-    base_url VARCHAR(1000) NOT NULL COMMENT '基础URL',
-    description TEXT COMMENT '站点描述',
-    start_urls JSON NOT NULL COMMENT '起始URL列表',
-    selectors JSON NOT NULL COMMENT 'CSS选择器配置',
-    rules JSON COMMENT '爬取规则',
-    enabled BOOLEAN DEFAULT TRUE COMMENT '是否启用',
-    status ENUM('ready', 'running', 'stopped', 'error') DEFAULT 'ready' COMMENT '状态',
-    last_run_at DATETIME NULL COMMENT '最后运行时间',
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
-    INDEX idx_name (name),
-    INDEX idx_status (status),
-    INDEX idx_enabled (enabled),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='爬虫站点配置表';
-`
-
-// 任务表
-const createTasksTable = `
-CREATE TABLE IF NOT EXISTS tasks (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(255) NOT NULL COMMENT '任务名称',
-    site_id INT NOT NULL COMMENT '站点ID',
-    status ENUM('pending', 'running', 'completed', 'failed', 'stopped') DEFAULT 'pending' COMMENT '任务状态',
-    config JSON COMMENT '任务配置',
-    start_time DATETIME NULL COMMENT '开始时间',
-    end_time DATETIME NULL COMMENT '结束时间',
-    total_urls INT DEFAULT 0 COMMENT '总URL数',
-    processed_urls INT DEFAULT 0 COMMENT '已处理URL数',
-    success_urls INT DEFAULT 0 COMMENT '成功URL数',
-    failed_urls INT DEFAULT 0 COMMENT '失败URL数',
-    items_count INT DEFAULT 0 COMMENT '抓取项目数',
-    error_message TEXT COMMENT '错误信息',
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
-    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
-    INDEX idx_site_id (site_id),
-    INDEX idx_status (status),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='爬虫任务表';
-`
-
-// 爬取数据表
-const createCrawlDataTable = `
-CREATE TABLE IF NOT EXISTS crawl_data (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    task_id INT NULL COMMENT '任务ID',
-    site_id INT NOT NULL COMMENT '站点ID',
-    url VARCHAR(2000) NOT NULL COMMENT '原始URL',
-    title TEXT COMMENT '标题',
-    content LONGTEXT COMMENT '内容',
-    description TEXT COMMENT '描述',
-    author VARCHAR(255) COMMENT '作者',
-    source VARCHAR(255) COMMENT '来源',
-    language VARCHAR(10) COMMENT '语言',
-    publish_date DATETIME NULL COMMENT '发布时间',
-    keywords JSON COMMENT '关键词',
-    tags JSON COMMENT '标签',
-    links JSON COMMENT '链接',
-    images JSON COMMENT '图片',
-    videos JSON COMMENT '视频',
-    metadata JSON COMMENT '额外元数据',
-    view_count INT DEFAULT 0 COMMENT '浏览量',
-    comment_count INT DEFAULT 0 COMMENT '评论数',
-    like_count INT DEFAULT 0 COMMENT '点赞数',
-    share_count INT DEFAULT 0 COMMENT '分享数',
-    status ENUM('new', 'processed', 'failed', 'skipped') DEFAULT 'new' COMMENT '状态',
-    crawl_time DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '抓取时间',
-    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE SET NULL,
-    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE,
-    UNIQUE KEY unique_url_site (url(500), site_id),
-    INDEX idx_task_id (task_id),
-    INDEX idx_site_id (site_id),
-    INDEX idx_publish_date (publish_date),
-    INDEX idx_crawl_time (crawl_time),
-    INDEX idx_status (status),
-    FULLTEXT INDEX ft_title_content (title, content)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='爬取数据表';
-`
-
-// 任务日志表
-const createTaskLogsTable = `
-CREATE TABLE IF NOT EXISTS task_logs (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    task_id INT NOT NULL COMMENT '任务ID',
-    level ENUM('debug', 'info', 'warn', 'error') DEFAULT 'info' COMMENT '日志级别',
-    message TEXT NOT NULL COMMENT '日志消息',
-    details JSON COMMENT '详细信息',
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
-    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
-    INDEX idx_task_id (task_id),
-    INDEX idx_level (level),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='任务日志表';
-`
-
-// 系统配置表
-const createSystemConfigTable = `
-CREATE TABLE IF NOT EXISTS system_config (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    config_key VARCHAR(255) NOT NULL UNIQUE COMMENT '配置键',
-    config_value JSON COMMENT '配置值',
-    description TEXT COMMENT '配置描述',
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
-    INDEX idx_config_key (config_key)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='系统配置表';
-` 
\ No newline at end of file
+// renameLegacyCrawlData 旧版存储自建的 crawl_data 表没有 site_id 字段，
+// 改名为 crawl_data_legacy 保留数据，再按统一结构重新建表
+func renameLegacyCrawlData(db *sql.DB) error {
+	exists, err := tableExists(db, "crawl_data")
+	if err != nil || !exists {
+		return err
+	}
+
+	var siteColumns int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'crawl_data' AND COLUMN_NAME = 'site_id'
+	`).Scan(&siteColumns)
+	if err != nil {
+		return fmt.Errorf("检查数据表字段失败: %w", err)
+	}
+	if siteColumns > 0 {
+		return nil
+	}
+
+	if _, err := db.Exec("RENAME TABLE crawl_data TO crawl_data_legacy"); err != nil {
+		return fmt.Errorf("重命名旧版数据表失败: %w", err)
+	}
+	return nil
+}
+
+// legacyColumns 引入迁移前通过 ALTER 补充的字段
+var legacyColumns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"tasks", "skipped_urls", "INT DEFAULT 0 COMMENT '被过滤URL数' AFTER failed_urls"},
+	{"sites", "schedule", "VARCHAR(100) NULL COMMENT '调度规则（cron表达式或时间间隔）' AFTER last_run_at"},
+	{"sites", "schedule_enabled", "BOOLEAN DEFAULT FALSE COMMENT '是否启用调度' AFTER schedule"},
+	{"sites", "next_run_at", "DATETIME NULL COMMENT '下次运行时间' AFTER schedule_enabled"},
+}
+
+// tableExists 判断当前数据库中是否存在指定表
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+	`, table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("检查数据表 %s 失败: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// addColumnIfMissing 字段不存在时添加字段
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`, table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("检查字段 %s.%s 失败: %w", table, column, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("添加字段 %s.%s 失败: %w", table, column, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// DB 数据库连接，附带所用驱动的 SQL 方言。
+// 语句统一使用 ? 占位符，执行前按方言转换。
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Exec 执行语句
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.Dialect.Rebind(query), args...)
+}
+
+// Query 执行查询
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.Dialect.Rebind(query), args...)
+}
+
+// QueryRow 执行只返回一行的查询
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.Dialect.Rebind(query), args...)
+}
+
+// Prepare 创建预编译语句
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.DB.Prepare(db.Dialect.Rebind(query))
+}
+
+// Insert 执行插入语句并返回新记录的自增ID
+func (db *DB) Insert(query string, args ...interface{}) (int64, error) {
+	if db.Dialect.Returning() {
+		var id int64
+		err := db.QueryRow(strings.TrimSpace(query)+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Begin 开始事务
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: db.Dialect}, nil
+}
+
+// Tx 数据库事务，执行前按方言转换占位符
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+// Exec 在事务中执行语句
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.Rebind(query), args...)
+}
+
+// Query 在事务中执行查询
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.Rebind(query), args...)
+}
+
+// QueryRow 在事务中执行只返回一行的查询
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.Rebind(query), args...)
+}
+
+// Prepare 在事务中创建预编译语句
+func (tx *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.Tx.Prepare(tx.dialect.Rebind(query))
+}
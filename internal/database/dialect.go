@@ -0,0 +1,156 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"example.com/m/v2/pkg/constants"
+)
+
+// Dialect 屏蔽不同数据库之间的 SQL 差异
+type Dialect interface {
+	// Driver 返回数据库驱动名
+	Driver() string
+	// Upsert 返回插入语句，keys 冲突时更新 updates 中的字段
+	Upsert(table string, columns, keys, updates []string) string
+	// Date 返回取日期部分的表达式
+	Date(expr string) string
+	// Search 返回在 columns 中全文搜索 keyword 的条件及参数
+	Search(columns []string, keyword string) (string, []interface{})
+	// Rebind 将语句中的 ? 占位符转换为驱动使用的格式
+	Rebind(query string) string
+	// Returning 插入后是否通过 RETURNING id 取得自增ID
+	Returning() bool
+}
+
+// NewDialect 返回驱动对应的方言
+func NewDialect(driver string) (Dialect, error) {
+	switch driver {
+	case constants.DatabaseDriverMySQL:
+		return mysqlDialect{}, nil
+	case constants.DatabaseDriverSQLite:
+		return sqliteDialect{}, nil
+	case constants.DatabaseDriverPostgres:
+		return postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+// mysqlDialect MySQL 方言
+type mysqlDialect struct{}
+
+func (mysqlDialect) Driver() string { return constants.DatabaseDriverMySQL }
+
+func (mysqlDialect) Upsert(table string, columns, keys, updates []string) string {
+	sets := make([]string, len(updates))
+	for i, column := range updates {
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+	}
+	return insertSQL(table, columns) + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+func (mysqlDialect) Date(expr string) string {
+	return "DATE(" + expr + ")"
+}
+
+func (mysqlDialect) Search(columns []string, keyword string) (string, []interface{}) {
+	return fmt.Sprintf("MATCH(%s) AGAINST(? IN BOOLEAN MODE)", strings.Join(columns, ", ")), []interface{}{keyword}
+}
+
+func (mysqlDialect) Rebind(query string) string { return query }
+
+func (mysqlDialect) Returning() bool { return false }
+
+// sqliteDialect SQLite 方言
+type sqliteDialect struct{}
+
+func (sqliteDialect) Driver() string { return constants.DatabaseDriverSQLite }
+
+func (sqliteDialect) Upsert(table string, columns, keys, updates []string) string {
+	return insertSQL(table, columns) + onConflictUpdate(keys, updates)
+}
+
+func (sqliteDialect) Date(expr string) string {
+	// CURRENT_TIMESTAMP 写入的是 UTC 时间，按本地时区取日期
+	return "date(" + expr + ", 'localtime')"
+}
+
+func (sqliteDialect) Search(columns []string, keyword string) (string, []interface{}) {
+	conds := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conds[i] = column + " LIKE ?"
+		args[i] = "%" + keyword + "%"
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+func (sqliteDialect) Returning() bool { return false }
+
+// postgresDialect PostgreSQL 方言
+type postgresDialect struct{}
+
+func (postgresDialect) Driver() string { return constants.DatabaseDriverPostgres }
+
+func (postgresDialect) Upsert(table string, columns, keys, updates []string) string {
+	return insertSQL(table, columns) + onConflictUpdate(keys, updates)
+}
+
+func (postgresDialect) Date(expr string) string {
+	return "(" + expr + ")::date"
+}
+
+func (postgresDialect) Search(columns []string, keyword string) (string, []interface{}) {
+	// 与迁移中的 GIN 表达式索引保持一致，才能命中索引
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = "coalesce(" + column + ", '')"
+	}
+	vector := "to_tsvector('simple', " + strings.Join(parts, " || ' ' || ") + ")"
+	return vector + " @@ plainto_tsquery('simple', ?)", []interface{}{keyword}
+}
+
+func (postgresDialect) Rebind(query string) string {
+	// 按顺序替换为 $1、$2...，跳过引号内的问号
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+			b.WriteByte(ch)
+		case ch == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+func (postgresDialect) Returning() bool { return true }
+
+// insertSQL 生成 INSERT 语句
+func insertSQL(table string, columns []string) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders)
+}
+
+// onConflictUpdate 生成 ON CONFLICT ... DO UPDATE 子句
+func onConflictUpdate(keys, updates []string) string {
+	sets := make([]string, len(updates))
+	for i, column := range updates {
+		sets[i] = fmt.Sprintf("%s = excluded.%s", column, column)
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(sets, ", "))
+}
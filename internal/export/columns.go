@@ -0,0 +1,127 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/repository"
+)
+
+// TimeLayout 表格文件（CSV、Excel）中的时间格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+// ListSeparator 列表字段（关键词、标签、链接等）写入表格文件时的分隔符
+const ListSeparator = "; "
+
+// Column 表格文件的一列，数据导出接口和文件存储共用
+type Column struct {
+	Name  string
+	Value func(item *repository.Item) interface{}
+}
+
+// Columns 全部可导出的列，按数据项字段顺序排列
+var Columns = []Column{
+	{"id", func(item *repository.Item) interface{} { return item.ID }},
+	{"task_id", func(item *repository.Item) interface{} {
+		if item.TaskID == nil {
+			return ""
+		}
+		return *item.TaskID
+	}},
+	{"site_id", func(item *repository.Item) interface{} { return item.SiteID }},
+	{"site_name", func(item *repository.Item) interface{} { return item.SiteName }},
+	{"url", func(item *repository.Item) interface{} { return item.URL }},
+	{"title", func(item *repository.Item) interface{} { return item.Title }},
+	{"content", func(item *repository.Item) interface{} { return item.Content }},
+	{"description", func(item *repository.Item) interface{} { return item.Description }},
+	{"author", func(item *repository.Item) interface{} { return item.Author }},
+	{"source", func(item *repository.Item) interface{} { return item.Source }},
+	{"language", func(item *repository.Item) interface{} { return item.Language }},
+	{"publish_date", func(item *repository.Item) interface{} { return formatTime(item.PublishDate) }},
+	{"keywords", func(item *repository.Item) interface{} { return strings.Join(item.Keywords, ListSeparator) }},
+	{"tags", func(item *repository.Item) interface{} { return strings.Join(item.Tags, ListSeparator) }},
+	{"links", func(item *repository.Item) interface{} { return strings.Join(item.Links, ListSeparator) }},
+	{"images", func(item *repository.Item) interface{} { return strings.Join(item.Images, ListSeparator) }},
+	{"videos", func(item *repository.Item) interface{} { return strings.Join(item.Videos, ListSeparator) }},
+	{"metadata", func(item *repository.Item) interface{} {
+		if len(item.Metadata) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(item.Metadata)
+		return string(data)
+	}},
+	{"view_count", func(item *repository.Item) interface{} { return item.ViewCount }},
+	{"comment_count", func(item *repository.Item) interface{} { return item.CommentCount }},
+	{"like_count", func(item *repository.Item) interface{} { return item.LikeCount }},
+	{"share_count", func(item *repository.Item) interface{} { return item.ShareCount }},
+	{"status", func(item *repository.Item) interface{} { return item.Status }},
+	{"crawl_time", func(item *repository.Item) interface{} { return formatTime(&item.CrawlTime) }},
+	{"content_hash", func(item *repository.Item) interface{} { return item.ContentHash }},
+	{"change_status", func(item *repository.Item) interface{} { return item.ChangeStatus }},
+	{"checked_at", func(item *repository.Item) interface{} { return formatTime(item.CheckedAt) }},
+}
+
+// Find 按名称查找列
+func Find(name string) (Column, bool) {
+	for _, column := range Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Select 按名称依次选取列，忽略空名称
+func Select(names []string) ([]Column, error) {
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		column, ok := Find(name)
+		if !ok {
+			return nil, fmt.Errorf("未知的导出字段: %s", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Header 返回各列的名称
+func Header(columns []Column) []string {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	return header
+}
+
+// Row 返回数据项各列的值，字符串经 SafeCell 处理
+func Row(columns []Column, item *repository.Item) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		row[i] = SafeCell(column.Value(item))
+	}
+	return row
+}
+
+// SafeCell 以 = + - @ 或制表符、回车开头的字符串前加单引号，
+// 避免表格软件打开文件时把抓取到的内容当作公式执行
+func SafeCell(value interface{}) interface{} {
+	s, ok := value.(string)
+	if ok && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return value
+}
+
+// formatTime 格式化时间，为空或零值时返回空字符串
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(TimeLayout)
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/repository"
+)
+
+func TestSafeCell(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"普通标题", "普通标题"},
+		{"a=b", "a=b"},
+		{"", ""},
+		{-5, -5},
+	}
+	for _, tt := range tests {
+		if got := SafeCell(tt.value); got != tt.want {
+			t.Errorf("SafeCell(%q) = %q，期望 %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRow(t *testing.T) {
+	columns, err := Select([]string{"title", " keywords ", "", "publish_date", "crawl_time", "view_count"})
+	if err != nil {
+		t.Fatalf("选取列失败: %v", err)
+	}
+	if got := Header(columns); len(got) != 5 || got[1] != "keywords" {
+		t.Fatalf("表头 = %v", got)
+	}
+
+	item := &repository.Item{
+		Title:     "=1+1",
+		Keywords:  []string{"go", "-x"},
+		CrawlTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ViewCount: 3,
+	}
+	want := []interface{}{"'=1+1", "go; -x", "", "2024-01-02 03:04:05", 3}
+	got := Row(columns, item)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 列 = %q，期望 %q", i, got[i], want[i])
+		}
+	}
+
+	if _, err := Select([]string{"title", "nope"}); err == nil {
+		t.Fatal("未知字段应返回错误")
+	}
+}
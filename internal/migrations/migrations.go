@@ -0,0 +1,333 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// files 各数据库驱动的迁移脚本，位于 sql/<driver>/<版本号>_<名称>.up.sql 和 .down.sql
+//
+//go:embed sql
+var files embed.FS
+
+// migrationFile 迁移脚本文件名格式
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// createVersionTable 记录已应用迁移的表
+const createVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`
+
+// Migration 一个编号的数据库迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移的应用状态
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Load 读取指定驱动的全部迁移，按版本号升序排列
+func Load(driver string) ([]Migration, error) {
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本 %d 存在多个名称: %s, %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("迁移版本 %d 缺少 up 脚本", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator 创建迁移执行器，并确保 schema_migrations 表存在
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(createVersionTable); err != nil {
+		return nil, fmt.Errorf("创建迁移版本表失败: %w", err)
+	}
+
+	return &Migrator{
+		db:         db,
+		driver:     driver,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest 返回最新的迁移版本号
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回当前已应用的最高版本号，未应用任何迁移时为 0
+func (m *Migrator) Version() (int, error) {
+	var version sql.NullInt64
+	if err := m.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询迁移版本失败: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// Status 返回全部迁移及其应用状态
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up 应用全部未应用的迁移，返回本次应用的迁移
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down 回滚最近一次应用的迁移，没有可回滚的迁移时返回 nil
+func (m *Migrator) Down() (*Migration, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+
+	rolledBack, err := m.To(target)
+	if err != nil || len(rolledBack) == 0 {
+		return nil, err
+	}
+	return &rolledBack[0], nil
+}
+
+// To 应用或回滚迁移直到指定版本，返回本次执行的迁移
+func (m *Migrator) To(target int) ([]Migration, error) {
+	if target < 0 || (target > 0 && !m.known(target)) {
+		return nil, fmt.Errorf("未知的迁移版本: %d", target)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var executed []Migration
+
+	// 回滚高于目标版本的迁移，从新到旧
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= target {
+			continue
+		}
+		if err := m.run(migration, false); err != nil {
+			return executed, err
+		}
+		executed = append(executed, migration)
+	}
+
+	// 应用不高于目标版本的迁移，从旧到新
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > target {
+			continue
+		}
+		if err := m.run(migration, true); err != nil {
+			return executed, err
+		}
+		executed = append(executed, migration)
+	}
+
+	return executed, nil
+}
+
+// run 在事务中执行一个迁移并更新版本记录
+func (m *Migrator) run(migration Migration, up bool) error {
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+		if script == "" {
+			return fmt.Errorf("迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+		}
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("执行迁移 %d_%s (%s) 失败: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES "+m.placeholders(3),
+			migration.Version, migration.Name, time.Now())
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = "+m.placeholders(1), migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("更新迁移版本失败: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// applied 查询已应用的迁移及应用时间
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("查询已应用迁移失败: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("读取已应用迁移失败: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// placeholders 生成 n 个参数占位符，PostgreSQL 使用 $1、$2...
+func (m *Migrator) placeholders(n int) string {
+	marks := make([]string, n)
+	for i := range marks {
+		if m.driver == "postgres" {
+			marks[i] = "$" + strconv.Itoa(i+1)
+		} else {
+			marks[i] = "?"
+		}
+	}
+	if n == 1 {
+		return marks[0]
+	}
+	return "(" + strings.Join(marks, ", ") + ")"
+}
+
+// known 判断版本号是否存在对应的迁移
+func (m *Migrator) known(version int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// splitStatements 按行尾分号拆分迁移脚本，忽略空语句和注释行
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,7 @@
+ALTER TABLE crawl_data
+    DROP INDEX idx_change_status,
+    DROP INDEX idx_site_content_hash,
+    DROP COLUMN checked_at,
+    DROP COLUMN change_status,
+    DROP COLUMN simhash,
+    DROP COLUMN content_hash;
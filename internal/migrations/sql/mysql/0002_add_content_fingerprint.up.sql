@@ -0,0 +1,8 @@
+-- 内容指纹和变更状态，用于去重和重复抓取时的变更检测
+ALTER TABLE crawl_data
+    ADD COLUMN content_hash CHAR(64) NULL COMMENT '规范化后的内容哈希' AFTER status,
+    ADD COLUMN simhash BIGINT NULL COMMENT '内容SimHash' AFTER content_hash,
+    ADD COLUMN change_status ENUM('new', 'updated', 'unchanged') DEFAULT 'new' COMMENT '最近一次抓取的变更状态' AFTER simhash,
+    ADD COLUMN checked_at DATETIME NULL COMMENT '最近一次抓取时间' AFTER crawl_time,
+    ADD INDEX idx_site_content_hash (site_id, content_hash),
+    ADD INDEX idx_change_status (change_status);
@@ -0,0 +1,3 @@
+-- 用户角色，引入角色前创建的用户拥有全部权限，升级为管理员
+ALTER TABLE users ADD COLUMN role ENUM('viewer', 'operator', 'editor', 'admin') NOT NULL DEFAULT 'viewer' COMMENT '角色' AFTER password_hash;
+UPDATE users SET role = 'admin';
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT '名称',
+    prefix VARCHAR(16) NOT NULL COMMENT '密钥前缀，用于识别',
+    key_hash CHAR(64) NOT NULL UNIQUE COMMENT '密钥的 SHA-256 哈希',
+    scopes JSON COMMENT '授权范围',
+    site_ids JSON COMMENT '允许访问的站点，为空时不限制',
+    expires_at DATETIME NULL COMMENT '过期时间，为空时不过期',
+    last_used_at DATETIME NULL COMMENT '最后使用时间',
+    last_used_ip VARCHAR(64) NULL COMMENT '最后使用的客户端IP',
+    created_by INT NULL COMMENT '创建者',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='API密钥表';
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    actor_type VARCHAR(20) NOT NULL COMMENT '操作者类型：user、api_key、anonymous',
+    actor_id INT NULL COMMENT '用户或API密钥ID',
+    actor_name VARCHAR(100) NULL COMMENT '用户名或API密钥名称',
+    action VARCHAR(50) NOT NULL COMMENT '操作，如 site.update',
+    target_type VARCHAR(50) NOT NULL COMMENT '操作对象类型',
+    target_id VARCHAR(100) NULL COMMENT '操作对象ID',
+    changes JSON COMMENT '变化的字段及前后的值',
+    details JSON COMMENT '附加信息',
+    ip VARCHAR(64) NULL COMMENT '客户端IP',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    INDEX idx_created_at (created_at),
+    INDEX idx_target (target_type, target_id),
+    INDEX idx_action (action)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='操作审计日志表';
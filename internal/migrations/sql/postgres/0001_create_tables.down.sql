@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS system_config;
+DROP TABLE IF EXISTS task_logs;
+DROP TABLE IF EXISTS crawl_data;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS sites;
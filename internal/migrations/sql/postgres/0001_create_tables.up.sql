@@ -0,0 +1,102 @@
+-- 爬虫站点配置表
+CREATE TABLE IF NOT EXISTS sites (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    base_url VARCHAR(1000) NOT NULL,
+    description TEXT,
+    start_urls JSONB NOT NULL,
+    selectors JSONB NOT NULL,
+    rules JSONB,
+    enabled BOOLEAN DEFAULT TRUE,
+    status VARCHAR(20) DEFAULT 'ready' CHECK (status IN ('ready', 'running', 'stopped', 'error')),
+    last_run_at TIMESTAMPTZ NULL,
+    schedule VARCHAR(100) NULL,
+    schedule_enabled BOOLEAN DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sites_status ON sites (status);
+CREATE INDEX IF NOT EXISTS idx_sites_enabled ON sites (enabled);
+CREATE INDEX IF NOT EXISTS idx_sites_created_at ON sites (created_at);
+
+-- 爬虫任务表
+CREATE TABLE IF NOT EXISTS tasks (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    site_id INTEGER NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed', 'stopped')),
+    config JSONB,
+    start_time TIMESTAMPTZ NULL,
+    end_time TIMESTAMPTZ NULL,
+    total_urls INTEGER DEFAULT 0,
+    processed_urls INTEGER DEFAULT 0,
+    success_urls INTEGER DEFAULT 0,
+    failed_urls INTEGER DEFAULT 0,
+    skipped_urls INTEGER DEFAULT 0,
+    items_count INTEGER DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_site_id ON tasks (site_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks (status);
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks (created_at);
+
+-- 爬取数据表
+CREATE TABLE IF NOT EXISTS crawl_data (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NULL REFERENCES tasks(id) ON DELETE SET NULL,
+    site_id INTEGER NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    url VARCHAR(2000) NOT NULL,
+    title TEXT,
+    content TEXT,
+    description TEXT,
+    author VARCHAR(255),
+    source VARCHAR(255),
+    language VARCHAR(10),
+    publish_date TIMESTAMPTZ NULL,
+    keywords JSONB,
+    tags JSONB,
+    links JSONB,
+    images JSONB,
+    videos JSONB,
+    metadata JSONB,
+    view_count INTEGER DEFAULT 0,
+    comment_count INTEGER DEFAULT 0,
+    like_count INTEGER DEFAULT 0,
+    share_count INTEGER DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'new' CHECK (status IN ('new', 'processed', 'failed', 'skipped')),
+    crawl_time TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (url, site_id)
+);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_task_id ON crawl_data (task_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_site_id ON crawl_data (site_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_publish_date ON crawl_data (publish_date);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_crawl_time ON crawl_data (crawl_time);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_status ON crawl_data (status);
+-- 全文索引，表达式需与 Dialect.Search 生成的条件一致
+CREATE INDEX IF NOT EXISTS idx_crawl_data_search ON crawl_data
+    USING GIN (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(content, '')));
+
+-- 任务日志表
+CREATE TABLE IF NOT EXISTS task_logs (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    level VARCHAR(10) DEFAULT 'info' CHECK (level IN ('debug', 'info', 'warn', 'error')),
+    message TEXT NOT NULL,
+    details JSONB,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_task_logs_task_id ON task_logs (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_logs_level ON task_logs (level);
+CREATE INDEX IF NOT EXISTS idx_task_logs_created_at ON task_logs (created_at);
+
+-- 系统配置表
+CREATE TABLE IF NOT EXISTS system_config (
+    id SERIAL PRIMARY KEY,
+    config_key VARCHAR(255) NOT NULL UNIQUE,
+    config_value JSONB,
+    description TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
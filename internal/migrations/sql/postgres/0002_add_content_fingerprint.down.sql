@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_crawl_data_change_status;
+DROP INDEX IF EXISTS idx_crawl_data_site_content_hash;
+ALTER TABLE crawl_data DROP COLUMN IF EXISTS checked_at;
+ALTER TABLE crawl_data DROP COLUMN IF EXISTS change_status;
+ALTER TABLE crawl_data DROP COLUMN IF EXISTS simhash;
+ALTER TABLE crawl_data DROP COLUMN IF EXISTS content_hash;
@@ -0,0 +1,7 @@
+-- 内容指纹和变更状态，用于去重和重复抓取时的变更检测
+ALTER TABLE crawl_data ADD COLUMN IF NOT EXISTS content_hash CHAR(64) NULL;
+ALTER TABLE crawl_data ADD COLUMN IF NOT EXISTS simhash BIGINT NULL;
+ALTER TABLE crawl_data ADD COLUMN IF NOT EXISTS change_status VARCHAR(20) DEFAULT 'new' CHECK (change_status IN ('new', 'updated', 'unchanged'));
+ALTER TABLE crawl_data ADD COLUMN IF NOT EXISTS checked_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_crawl_data_site_content_hash ON crawl_data (site_id, content_hash);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_change_status ON crawl_data (change_status);
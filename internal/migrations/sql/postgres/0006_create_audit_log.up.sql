@@ -0,0 +1,17 @@
+-- 操作审计日志表，不关联用户表，删除用户后仍保留记录
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id INTEGER NULL,
+    actor_name VARCHAR(100) NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id VARCHAR(100) NULL,
+    changes JSONB,
+    details JSONB,
+    ip VARCHAR(64) NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log (action);
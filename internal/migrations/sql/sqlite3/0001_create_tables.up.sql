@@ -0,0 +1,99 @@
+-- 爬虫站点配置表
+CREATE TABLE IF NOT EXISTS sites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    base_url VARCHAR(1000) NOT NULL,
+    description TEXT,
+    start_urls TEXT NOT NULL,
+    selectors TEXT NOT NULL,
+    rules TEXT,
+    enabled BOOLEAN DEFAULT TRUE,
+    status TEXT DEFAULT 'ready' CHECK (status IN ('ready', 'running', 'stopped', 'error')),
+    last_run_at DATETIME NULL,
+    schedule VARCHAR(100) NULL,
+    schedule_enabled BOOLEAN DEFAULT FALSE,
+    next_run_at DATETIME NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sites_status ON sites (status);
+CREATE INDEX IF NOT EXISTS idx_sites_enabled ON sites (enabled);
+CREATE INDEX IF NOT EXISTS idx_sites_created_at ON sites (created_at);
+
+-- 爬虫任务表
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    site_id INTEGER NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed', 'stopped')),
+    config TEXT,
+    start_time DATETIME NULL,
+    end_time DATETIME NULL,
+    total_urls INTEGER DEFAULT 0,
+    processed_urls INTEGER DEFAULT 0,
+    success_urls INTEGER DEFAULT 0,
+    failed_urls INTEGER DEFAULT 0,
+    skipped_urls INTEGER DEFAULT 0,
+    items_count INTEGER DEFAULT 0,
+    error_message TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_site_id ON tasks (site_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks (status);
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks (created_at);
+
+-- 爬取数据表
+CREATE TABLE IF NOT EXISTS crawl_data (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NULL REFERENCES tasks(id) ON DELETE SET NULL,
+    site_id INTEGER NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    url VARCHAR(2000) NOT NULL,
+    title TEXT,
+    content TEXT,
+    description TEXT,
+    author VARCHAR(255),
+    source VARCHAR(255),
+    language VARCHAR(10),
+    publish_date DATETIME NULL,
+    keywords TEXT,
+    tags TEXT,
+    links TEXT,
+    images TEXT,
+    videos TEXT,
+    metadata TEXT,
+    view_count INTEGER DEFAULT 0,
+    comment_count INTEGER DEFAULT 0,
+    like_count INTEGER DEFAULT 0,
+    share_count INTEGER DEFAULT 0,
+    status TEXT DEFAULT 'new' CHECK (status IN ('new', 'processed', 'failed', 'skipped')),
+    crawl_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (url, site_id)
+);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_task_id ON crawl_data (task_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_site_id ON crawl_data (site_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_publish_date ON crawl_data (publish_date);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_crawl_time ON crawl_data (crawl_time);
+CREATE INDEX IF NOT EXISTS idx_crawl_data_status ON crawl_data (status);
+
+-- 任务日志表
+CREATE TABLE IF NOT EXISTS task_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    level TEXT DEFAULT 'info' CHECK (level IN ('debug', 'info', 'warn', 'error')),
+    message TEXT NOT NULL,
+    details TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_task_logs_task_id ON task_logs (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_logs_level ON task_logs (level);
+CREATE INDEX IF NOT EXISTS idx_task_logs_created_at ON task_logs (created_at);
+
+-- 系统配置表
+CREATE TABLE IF NOT EXISTS system_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    config_key VARCHAR(255) NOT NULL UNIQUE,
+    config_value TEXT,
+    description TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_crawl_data_change_status;
+DROP INDEX IF EXISTS idx_crawl_data_site_content_hash;
+ALTER TABLE crawl_data DROP COLUMN checked_at;
+ALTER TABLE crawl_data DROP COLUMN change_status;
+ALTER TABLE crawl_data DROP COLUMN simhash;
+ALTER TABLE crawl_data DROP COLUMN content_hash;
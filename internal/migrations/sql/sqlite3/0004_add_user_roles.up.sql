@@ -0,0 +1,3 @@
+-- 用户角色，引入角色前创建的用户拥有全部权限，升级为管理员
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'viewer' CHECK (role IN ('viewer', 'operator', 'editor', 'admin'));
+UPDATE users SET role = 'admin';
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 进程内的令牌桶存储，限额只在当前实例内有效
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// memoryBucket 令牌桶及其恢复满额的时间，满额后的令牌桶与新建的等价，可以清理
+type memoryBucket struct {
+	bucket
+	full time.Time
+}
+
+// NewMemoryStore 创建进程内的令牌桶存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take 从 key 对应的令牌桶中取一个令牌，新的令牌桶是满的
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.burst()), updated: now}}
+		s.buckets[key] = b
+	}
+	result := b.take(limit, now)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep 定期删除已恢复满额的令牌桶，避免客户端数量增长导致内存持续增加
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{PerMinute: 60, Burst: 3} // 每秒补充一个令牌
+
+	tests := []struct {
+		name       string
+		at         time.Duration // 距 start 的时间
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"新桶是满的", 0, true, 2, 0, time.Second},
+		{"连续请求", 0, true, 1, 0, 2 * time.Second},
+		{"用完突发额度", 0, true, 0, 0, 3 * time.Second},
+		{"超出限额", 0, false, 0, time.Second, 3 * time.Second},
+		{"半个令牌", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+		{"补充一个令牌", time.Second, true, 0, 0, 3 * time.Second},
+		{"补充不超过容量", time.Minute, true, 2, 0, time.Second},
+	}
+
+	store := NewMemoryStore()
+	for _, tt := range tests {
+		result, err := store.Take("client", limit, start.Add(tt.at))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if result.Allowed != tt.allowed || result.Remaining != tt.remaining || result.Limit != 3 ||
+			result.RetryAfter != tt.retryAfter || result.Reset != tt.reset {
+			t.Fatalf("%s: %+v", tt.name, result)
+		}
+	}
+}
+
+func TestMemoryStoreKeysAndDefaults(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	// 未设置 Burst 时容量等于每分钟请求数
+	limit := Limit{PerMinute: 2}
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take("a", limit, now); !result.Allowed || result.Limit != 2 {
+			t.Fatalf("第 %d 次请求: %+v", i+1, result)
+		}
+	}
+	if result, _ := store.Take("a", limit, now); result.Allowed || result.RetryAfter != 30*time.Second {
+		t.Fatalf("超出限额: %+v", result)
+	}
+	// 不同的 key 使用各自的令牌桶
+	if result, _ := store.Take("b", limit, now); !result.Allowed {
+		t.Fatalf("其他 key 被限流: %+v", result)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Now()
+	limit := Limit{PerMinute: 60, Burst: 60}
+
+	store.Take("idle", limit, start)
+	for i := 0; i < 60; i++ {
+		store.Take("busy", limit, start)
+	}
+
+	// new 在 30 秒时取走 45 个令牌，要到 75 秒才恢复满额
+	for i := 0; i < 45; i++ {
+		store.Take("new", limit, start.Add(30*time.Second))
+	}
+	if len(store.buckets) != 3 {
+		t.Fatalf("未到清理间隔时不应清理: %d 个令牌桶", len(store.buckets))
+	}
+	// 一分钟后 idle 早已恢复满额，busy 刚好恢复满额，都可以清理
+	store.Take("other", limit, start.Add(sweepInterval))
+	for _, key := range []string{"idle", "busy"} {
+		if _, ok := store.buckets[key]; ok {
+			t.Fatalf("已恢复满额的令牌桶 %s 未被清理", key)
+		}
+	}
+	if _, ok := store.buckets["new"]; !ok {
+		t.Fatal("未恢复满额的令牌桶被清理")
+	}
+}
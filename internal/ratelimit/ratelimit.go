@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit 令牌桶限额：每分钟补充 PerMinute 个令牌，最多积累 Burst 个
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// rate 每秒补充的令牌数
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// burst 桶容量，未设置时等于每分钟请求数
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.PerMinute
+}
+
+// Result 一次取令牌的结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时距下一个令牌可用的时间
+	Reset      time.Duration // 令牌桶恢复满额所需的时间
+}
+
+// Store 令牌桶存储。默认使用进程内的 MemoryStore，
+// 多实例部署时可替换为基于 Redis 等共享存储的实现，使各实例共用同一份限额
+type Store interface {
+	// Take 从 key 对应的令牌桶中取一个令牌
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+// bucket 令牌桶状态
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take 按经过的时间补充令牌后取一个令牌，返回本次结果。
+// 共享存储的实现可以复用相同的计算，只需保证读写 bucket 的原子性
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	rate := limit.rate()
+	burst := float64(limit.burst())
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	result := Result{Limit: limit.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((burst - b.tokens) / rate)
+	return result
+}
+
+// seconds 将秒数转换为时间间隔
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// APIKey 供程序调用的API密钥，只保存密钥哈希
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	SiteIDs    []int      `json:"site_ids"` // 允许访问的站点，为空时不限制
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedBy  *int       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Expired 密钥是否已过期
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// AllowsSite 密钥是否可以访问指定站点
+func (k *APIKey) AllowsSite(siteID int) bool {
+	if len(k.SiteIDs) == 0 {
+		return true
+	}
+	for _, id := range k.SiteIDs {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRepository API密钥存取接口
+type APIKeyRepository interface {
+	// List 按ID升序返回全部密钥
+	List() ([]APIKey, error)
+	// Get 返回指定密钥，不存在时返回 ErrNotFound
+	Get(id int) (*APIKey, error)
+	// GetByHash 按密钥哈希返回密钥，不存在时返回 ErrNotFound
+	GetByHash(hash string) (*APIKey, error)
+	// Create 创建密钥并回填ID
+	Create(key *APIKey) error
+	// Delete 删除密钥
+	Delete(id int) error
+	// TouchUsed 记录最后使用时间和客户端IP
+	TouchUsed(id int, at time.Time, ip string) error
+}
+
+// sqlAPIKeyRepository 基于数据库的API密钥存取
+type sqlAPIKeyRepository struct {
+	db *database.DB
+}
+
+const selectAPIKeys = `SELECT id, name, prefix, key_hash, scopes, site_ids, expires_at, last_used_at, last_used_ip,
+	created_by, created_at FROM api_keys`
+
+// scanAPIKey 扫描一行密钥数据
+func scanAPIKey(row scanner) (*APIKey, error) {
+	var key APIKey
+	var scopes, siteIDs, lastUsedIP sql.NullString
+	var expiresAt, lastUsedAt sql.NullTime
+	var createdBy sql.NullInt64
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &siteIDs, &expiresAt, &lastUsedAt,
+		&lastUsedIP, &createdBy, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	decodeJSON(scopes, &key.Scopes)
+	decodeJSON(siteIDs, &key.SiteIDs)
+	key.ExpiresAt = timePtr(expiresAt)
+	key.LastUsedAt = timePtr(lastUsedAt)
+	key.LastUsedIP = lastUsedIP.String
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.CreatedBy = &id
+	}
+	return &key, nil
+}
+
+func (r *sqlAPIKeyRepository) List() ([]APIKey, error) {
+	rows, err := r.db.Query(selectAPIKeys + " ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *sqlAPIKeyRepository) Get(id int) (*APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(selectAPIKeys+" WHERE id = ?", id))
+	return key, notFound(err)
+}
+
+func (r *sqlAPIKeyRepository) GetByHash(hash string) (*APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(selectAPIKeys+" WHERE key_hash = ?", hash))
+	return key, notFound(err)
+}
+
+func (r *sqlAPIKeyRepository) Create(key *APIKey) error {
+	now := time.Now()
+	id, err := r.db.Insert(`
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, site_ids, expires_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, key.Name, key.Prefix, key.KeyHash, encodeJSON(key.Scopes), encodeJSON(key.SiteIDs), key.ExpiresAt,
+		key.CreatedBy, now)
+	if err != nil {
+		return err
+	}
+
+	key.ID = int(id)
+	key.CreatedAt = now
+	return nil
+}
+
+func (r *sqlAPIKeyRepository) Delete(id int) error {
+	return affected(r.db.Exec("DELETE FROM api_keys WHERE id = ?", id))
+}
+
+func (r *sqlAPIKeyRepository) TouchUsed(id int, at time.Time, ip string) error {
+	return affected(r.db.Exec("UPDATE api_keys SET last_used_at = ?, last_used_ip = ? WHERE id = ?", at, ip, id))
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// AuditEntry 一条操作审计记录
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	ActorType  string                 `json:"actor_type"` // user、api_key，未启用认证时为 anonymous
+	ActorID    *int                   `json:"actor_id"`
+	ActorName  string                 `json:"actor_name"`
+	Action     string                 `json:"action"` // 如 site.update、task.start
+	TargetType string                 `json:"target_type"`
+	TargetID   string                 `json:"target_id"`
+	Changes    map[string]AuditChange `json:"changes"` // 发生变化的字段
+	Details    map[string]interface{} `json:"details"` // 附加信息，如运行站点时创建的任务ID
+	IP         string                 `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditChange 字段变化前后的值，创建时 Before 为空，删除时 After 为空
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter 审计记录查询条件
+type AuditFilter struct {
+	ActorType  string
+	Actor      string // 操作者名称
+	Action     string
+	TargetType string
+	TargetID   string
+	StartDate  string // 操作时间下限
+	EndDate    string // 操作时间上限
+	Pagination
+}
+
+// AuditRepository 审计记录存取接口
+type AuditRepository interface {
+	// List 按时间倒序返回当前页的审计记录及总数
+	List(filter AuditFilter) ([]AuditEntry, int, error)
+	// Create 写入一条审计记录并回填ID
+	Create(entry *AuditEntry) error
+}
+
+// sqlAuditRepository 基于数据库的审计记录存取
+type sqlAuditRepository struct {
+	db *database.DB
+}
+
+// where 构建审计记录查询条件
+func (f AuditFilter) where() (string, []interface{}) {
+	where := "1=1"
+	args := []interface{}{}
+
+	for _, cond := range []struct {
+		column string
+		value  string
+	}{
+		{"actor_type", f.ActorType},
+		{"actor_name", f.Actor},
+		{"action", f.Action},
+		{"target_type", f.TargetType},
+		{"target_id", f.TargetID},
+	} {
+		if cond.value != "" {
+			where += " AND " + cond.column + " = ?"
+			args = append(args, cond.value)
+		}
+	}
+	if f.StartDate != "" {
+		where += " AND created_at >= ?"
+		args = append(args, f.StartDate)
+	}
+	if f.EndDate != "" {
+		where += " AND created_at <= ?"
+		args = append(args, f.EndDate)
+	}
+	return where, args
+}
+
+func (r *sqlAuditRepository) List(filter AuditFilter) ([]AuditEntry, int, error) {
+	where, args := filter.where()
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM audit_log WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit, limitArgs := filter.limitSQL()
+	rows, err := r.db.Query(`
+		SELECT id, actor_type, actor_id, actor_name, action, target_type, target_id, changes, details, ip, created_at
+		FROM audit_log
+		WHERE `+where+`
+		ORDER BY created_at DESC, id DESC`+limit, append(args, limitArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		var actorID sql.NullInt64
+		var actorName, targetID, changes, details, ip sql.NullString
+		err := rows.Scan(&entry.ID, &entry.ActorType, &actorID, &actorName, &entry.Action, &entry.TargetType,
+			&targetID, &changes, &details, &ip, &entry.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			entry.ActorID = &id
+		}
+		entry.ActorName = actorName.String
+		entry.TargetID = targetID.String
+		entry.IP = ip.String
+		decodeJSON(changes, &entry.Changes)
+		decodeJSON(details, &entry.Details)
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+func (r *sqlAuditRepository) Create(entry *AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	id, err := r.db.Insert(`
+		INSERT INTO audit_log (actor_type, actor_id, actor_name, action, target_type, target_id, changes, details, ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ActorType, entry.ActorID, entry.ActorName, entry.Action, entry.TargetType, entry.TargetID,
+		encodeJSON(entry.Changes), encodeJSON(entry.Details), entry.IP, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+	entry.ID = int(id)
+	return nil
+}
@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/pkg/constants"
+	"example.com/m/v2/pkg/models"
+)
+
+// Item 爬取的数据记录
+type Item struct {
+	ID           int                    `json:"id"`
+	TaskID       *int                   `json:"task_id"`
+	SiteID       int                    `json:"site_id"`
+	SiteName     string                 `json:"site_name"`
+	URL          string                 `json:"url"`
+	Title        string                 `json:"title"`
+	Content      string                 `json:"content"`
+	Description  string                 `json:"description"`
+	Author       string                 `json:"author"`
+	Source       string                 `json:"source"`
+	Language     string                 `json:"language"`
+	PublishDate  *time.Time             `json:"publish_date"`
+	Keywords     []string               `json:"keywords"`
+	Tags         []string               `json:"tags"`
+	Links        []string               `json:"links"`
+	Images       []string               `json:"images"`
+	Videos       []string               `json:"videos"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	ViewCount    int                    `json:"view_count"`
+	CommentCount int                    `json:"comment_count"`
+	LikeCount    int                    `json:"like_count"`
+	ShareCount   int                    `json:"share_count"`
+	Status       string                 `json:"status"`
+	CrawlTime    time.Time              `json:"crawl_time"`
+	ContentHash  string                 `json:"content_hash"`
+	ChangeStatus string                 `json:"change_status"` // 最近一次抓取的变更状态: new, updated, unchanged
+	CheckedAt    *time.Time             `json:"checked_at"`    // 最近一次抓取时间，内容未变化时不更新 crawl_time
+}
+
+// ItemFilter 数据查询条件
+type ItemFilter struct {
+	SiteID       int
+	SiteIDs      []int // 限定在这些站点内，为空时不限制
+	Status       string
+	ChangeStatus string
+	Keyword      string // 标题或内容包含关键词
+	Search       string // 全文搜索
+	StartDate    string // 抓取时间下限
+	EndDate      string // 抓取时间上限
+	// WithContent 是否返回正文，列表默认不返回以减少数据量
+	WithContent bool
+	Pagination
+}
+
+// SiteCount 站点数据量
+type SiteCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// DateCount 每日数据量
+type DateCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// ItemStats 数据统计
+type ItemStats struct {
+	Total  int
+	Today  int
+	BySite []SiteCount // 数据量最多的10个站点
+	Recent []DateCount // 最近7天每日数据量，按日期倒序
+}
+
+// ItemRepository 数据存取接口
+type ItemRepository interface {
+	// List 按抓取时间倒序返回当前页的数据及总数
+	List(filter ItemFilter) ([]Item, int, error)
+	// Each 按抓取时间倒序逐条回调满足条件的数据，不一次性载入内存，fn 返回错误时停止
+	Each(filter ItemFilter, fn func(item *Item) error) error
+	// Get 返回指定数据，不存在时返回 ErrNotFound
+	Get(id int) (*Item, error)
+	// Save 保存数据并回填ID，同一站点的相同URL更新已有记录，返回是否新建了记录
+	Save(item *Item) (bool, error)
+	// Delete 删除数据
+	Delete(id int) error
+	// Stats 返回数据统计
+	Stats() (*ItemStats, error)
+}
+
+// sqlItemRepository 基于数据库的数据存取
+type sqlItemRepository struct {
+	db *database.DB
+}
+
+// itemColumns 数据查询字段，顺序与 scanItem 一致，content 由调用方决定是否查询
+const itemColumns = `cd.id, cd.task_id, cd.site_id, s.name, cd.url, cd.title, %s,
+	cd.description, cd.author, cd.source, cd.language, cd.publish_date,
+	cd.keywords, cd.tags, cd.links, cd.images, cd.videos, cd.metadata,
+	cd.view_count, cd.comment_count, cd.like_count, cd.share_count,
+	cd.status, cd.crawl_time, cd.content_hash, cd.change_status, cd.checked_at`
+
+// selectItems 返回数据查询语句的 SELECT ... FROM 部分
+func selectItems(withContent bool) string {
+	content := "''"
+	if withContent {
+		content = "cd.content"
+	}
+	return "SELECT " + strings.Replace(itemColumns, "%s", content, 1) + `
+		FROM crawl_data cd
+		LEFT JOIN sites s ON cd.site_id = s.id`
+}
+
+// scanItem 扫描一行数据
+func scanItem(row scanner) (*Item, error) {
+	var item Item
+	var taskID sql.NullInt64
+	var siteName, title, content, description, author, source, language, status sql.NullString
+	var keywords, tags, links, images, videos, metadata sql.NullString
+	var contentHash, changeStatus sql.NullString
+	var publishDate, checkedAt sql.NullTime
+
+	err := row.Scan(
+		&item.ID, &taskID, &item.SiteID, &siteName, &item.URL, &title, &content,
+		&description, &author, &source, &language, &publishDate,
+		&keywords, &tags, &links, &images, &videos, &metadata,
+		&item.ViewCount, &item.CommentCount, &item.LikeCount, &item.ShareCount,
+		&status, &item.CrawlTime, &contentHash, &changeStatus, &checkedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if taskID.Valid {
+		id := int(taskID.Int64)
+		item.TaskID = &id
+	}
+	item.SiteName = siteName.String
+	item.Title = title.String
+	item.Content = content.String
+	item.Description = description.String
+	item.Author = author.String
+	item.Source = source.String
+	item.Language = language.String
+	item.Status = status.String
+	item.PublishDate = timePtr(publishDate)
+	item.ContentHash = contentHash.String
+	item.ChangeStatus = changeStatus.String
+	item.CheckedAt = timePtr(checkedAt)
+
+	decodeJSON(keywords, &item.Keywords)
+	decodeJSON(tags, &item.Tags)
+	decodeJSON(links, &item.Links)
+	decodeJSON(images, &item.Images)
+	decodeJSON(videos, &item.Videos)
+	decodeJSON(metadata, &item.Metadata)
+
+	return &item, nil
+}
+
+// where 构建数据查询条件
+func (f ItemFilter) where(dialect database.Dialect) (string, []interface{}) {
+	where := "1=1"
+	args := []interface{}{}
+
+	if f.SiteID > 0 {
+		where += " AND cd.site_id = ?"
+		args = append(args, f.SiteID)
+	}
+	if len(f.SiteIDs) > 0 {
+		cond, idArgs := inInts("cd.site_id", f.SiteIDs)
+		where += " AND " + cond
+		args = append(args, idArgs...)
+	}
+	if f.Status != "" {
+		where += " AND cd.status = ?"
+		args = append(args, f.Status)
+	}
+	if f.ChangeStatus != "" {
+		where += " AND cd.change_status = ?"
+		args = append(args, f.ChangeStatus)
+	}
+	if f.Keyword != "" {
+		where += " AND (cd.title LIKE ? OR cd.content LIKE ?)"
+		args = append(args, "%"+f.Keyword+"%", "%"+f.Keyword+"%")
+	}
+	if f.Search != "" {
+		cond, searchArgs := dialect.Search([]string{"cd.title", "cd.content"}, f.Search)
+		where += " AND " + cond
+		args = append(args, searchArgs...)
+	}
+	if f.StartDate != "" {
+		where += " AND cd.crawl_time >= ?"
+		args = append(args, f.StartDate)
+	}
+	if f.EndDate != "" {
+		where += " AND cd.crawl_time <= ?"
+		args = append(args, f.EndDate)
+	}
+	return where, args
+}
+
+func (r *sqlItemRepository) List(filter ItemFilter) ([]Item, int, error) {
+	where, args := filter.where(r.db.Dialect)
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM crawl_data cd WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	var items []Item
+	err := r.Each(filter, func(item *Item) error {
+		items = append(items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *sqlItemRepository) Each(filter ItemFilter, fn func(item *Item) error) error {
+	where, args := filter.where(r.db.Dialect)
+	limit, limitArgs := filter.limitSQL()
+	query := selectItems(filter.WithContent) + `
+		WHERE ` + where + `
+		ORDER BY cd.crawl_time DESC, cd.id DESC` + limit
+
+	rows, err := r.db.Query(query, append(args, limitArgs...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *sqlItemRepository) Get(id int) (*Item, error) {
+	item, err := scanItem(r.db.QueryRow(selectItems(true)+" WHERE cd.id = ?", id))
+	return item, notFound(err)
+}
+
+// itemSaveColumns 保存数据时写入的字段，顺序与 UpsertItem 的参数一致
+var itemSaveColumns = []string{
+	"task_id", "site_id", "url", "title", "content", "description", "author", "source", "language", "publish_date",
+	"keywords", "tags", "links", "images", "videos", "metadata", "status", "crawl_time",
+	"content_hash", "simhash", "change_status", "checked_at",
+}
+
+// itemSaveKeys 数据唯一键，同一站点的相同URL更新已有记录
+var itemSaveKeys = []string{"url", "site_id"}
+
+// Execer 数据库连接和事务共有的执行方法
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// UpsertItem 写入一条数据，同一站点的相同URL更新已有记录。
+// 数据接口和爬虫的数据库存储共用此写入；内容哈希、SimHash 和变更状态由调用方计算，
+// 状态、抓取时间和检查时间为空时补全并回填
+func UpsertItem(q Execer, dialect database.Dialect, item *Item, simhash uint64) error {
+	var updates []string
+	for _, column := range itemSaveColumns {
+		if column != "url" && column != "site_id" {
+			updates = append(updates, column)
+		}
+	}
+
+	var taskID, publishDate interface{}
+	if item.TaskID != nil {
+		taskID = *item.TaskID
+	}
+	if item.PublishDate != nil {
+		publishDate = *item.PublishDate
+	}
+	if item.Status == "" {
+		item.Status = "new"
+	}
+	metadata := item.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if item.CrawlTime.IsZero() {
+		item.CrawlTime = time.Now()
+	}
+	if item.CheckedAt == nil {
+		checkedAt := time.Now()
+		item.CheckedAt = &checkedAt
+	}
+
+	_, err := q.Exec(dialect.Upsert("crawl_data", itemSaveColumns, itemSaveKeys, updates),
+		taskID, item.SiteID, item.URL, item.Title, item.Content, item.Description, item.Author, item.Source,
+		item.Language, publishDate,
+		encodeJSON(nonNil(item.Keywords)), encodeJSON(nonNil(item.Tags)), encodeJSON(nonNil(item.Links)),
+		encodeJSON(nonNil(item.Images)), encodeJSON(nonNil(item.Videos)), encodeJSON(metadata),
+		item.Status, item.CrawlTime, item.ContentHash, int64(simhash), item.ChangeStatus, *item.CheckedAt)
+	return err
+}
+
+func (r *sqlItemRepository) Save(item *Item) (bool, error) {
+	var oldHash sql.NullString
+	err := r.db.QueryRow("SELECT content_hash FROM crawl_data WHERE url = ? AND site_id = ?", item.URL, item.SiteID).Scan(&oldHash)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	created := err == sql.ErrNoRows
+
+	hash, simhash := item.fingerprint()
+	item.ContentHash = hash
+	item.ChangeStatus = changeStatus(created, oldHash.String, hash)
+	checkedAt := time.Now()
+	item.CheckedAt = &checkedAt
+	if err := UpsertItem(r.db, r.db.Dialect, item, simhash); err != nil {
+		return false, err
+	}
+
+	// 插入和更新都按唯一键取回ID
+	err = r.db.QueryRow("SELECT id FROM crawl_data WHERE url = ? AND site_id = ?", item.URL, item.SiteID).Scan(&item.ID)
+	return created, err
+}
+
+// fingerprint 按 models.Item 的规则计算内容哈希和 SimHash
+func (item *Item) fingerprint() (string, uint64) {
+	m := models.Item{Title: item.Title, Description: item.Description, Content: item.Content}
+	return m.ContentHash(), m.SimHash()
+}
+
+// changeStatus 比较新旧内容哈希得出变更状态
+func changeStatus(created bool, oldHash, hash string) string {
+	switch {
+	case created:
+		return constants.ChangeStatusNew
+	case oldHash == hash:
+		return constants.ChangeStatusUnchanged
+	default:
+		return constants.ChangeStatusUpdated
+	}
+}
+
+func (r *sqlItemRepository) Delete(id int) error {
+	return affected(r.db.Exec("DELETE FROM crawl_data WHERE id = ?", id))
+}
+
+func (r *sqlItemRepository) Stats() (*ItemStats, error) {
+	var stats ItemStats
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM crawl_data").Scan(&stats.Total); err != nil {
+		return nil, err
+	}
+
+	crawlDate := r.db.Dialect.Date("crawl_time")
+	today := time.Now().Format("2006-01-02")
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM crawl_data WHERE "+crawlDate+" = ?", today).Scan(&stats.Today); err != nil {
+		return nil, err
+	}
+
+	siteRows, err := r.db.Query(`
+		SELECT s.name, COUNT(cd.id) as count
+		FROM sites s
+		LEFT JOIN crawl_data cd ON s.id = cd.site_id
+		GROUP BY s.id, s.name
+		ORDER BY count DESC
+		LIMIT 10
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer siteRows.Close()
+
+	for siteRows.Next() {
+		var sc SiteCount
+		if err := siteRows.Scan(&sc.Name, &sc.Count); err != nil {
+			return nil, err
+		}
+		stats.BySite = append(stats.BySite, sc)
+	}
+
+	weekAgo := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	recentRows, err := r.db.Query(`
+		SELECT `+crawlDate+` as date, COUNT(*) as count
+		FROM crawl_data
+		WHERE `+crawlDate+` >= ?
+		GROUP BY `+crawlDate+`
+		ORDER BY date DESC
+	`, weekAgo)
+	if err != nil {
+		return nil, err
+	}
+	defer recentRows.Close()
+
+	for recentRows.Next() {
+		var date interface{}
+		var dc DateCount
+		if err := recentRows.Scan(&date, &dc.Count); err != nil {
+			return nil, err
+		}
+		dc.Date = formatDate(date)
+		stats.Recent = append(stats.Recent, dc)
+	}
+
+	return &stats, nil
+}
+
+// formatDate 将数据库返回的日期统一格式化为 2006-01-02
+func formatDate(v interface{}) string {
+	switch d := v.(type) {
+	case time.Time:
+		return d.Format("2006-01-02")
+	case []byte:
+		return string(d)
+	case string:
+		return d
+	default:
+		return ""
+	}
+}
+
+// nonNil 将 nil 切片转换为空切片，使其编码为 [] 而不是 null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// TaskLog 任务日志
+type TaskLog struct {
+	ID        int
+	TaskID    int
+	Level     string
+	Message   string
+	Details   string // JSON 格式的详细信息，可能为空
+	CreatedAt time.Time
+}
+
+// LogFilter 日志查询条件
+type LogFilter struct {
+	TaskID int // 为 0 时查询所有任务
+	Level  string
+	Pagination
+}
+
+// LogRepository 任务日志存取接口
+type LogRepository interface {
+	// List 按时间倒序返回当前页的日志
+	List(filter LogFilter) ([]TaskLog, error)
+	// Create 写入一条日志并回填ID
+	Create(log *TaskLog) error
+	// CreateBatch 批量写入日志，不回填ID
+	CreateBatch(logs []TaskLog) error
+}
+
+// ConfigRepository 系统配置存取接口
+type ConfigRepository interface {
+	// Get 返回配置项的值，不存在时返回 ErrNotFound
+	Get(key string) (string, error)
+	// Save 保存配置项，已存在时覆盖
+	Save(key, value, description string) error
+}
+
+// sqlLogRepository 基于数据库的任务日志存取
+type sqlLogRepository struct {
+	db *database.DB
+}
+
+func (r *sqlLogRepository) List(filter LogFilter) ([]TaskLog, error) {
+	where := "1=1"
+	args := []interface{}{}
+
+	if filter.TaskID > 0 {
+		where += " AND task_id = ?"
+		args = append(args, filter.TaskID)
+	}
+	if filter.Level != "" {
+		where += " AND level = ?"
+		args = append(args, filter.Level)
+	}
+
+	limit, limitArgs := filter.limitSQL()
+	rows, err := r.db.Query(`
+		SELECT id, task_id, level, message, details, created_at
+		FROM task_logs
+		WHERE `+where+`
+		ORDER BY created_at DESC, id DESC`+limit, append(args, limitArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []TaskLog
+	for rows.Next() {
+		var log TaskLog
+		var details sql.NullString // details 可能为 NULL
+		if err := rows.Scan(&log.ID, &log.TaskID, &log.Level, &log.Message, &details, &log.CreatedAt); err != nil {
+			return nil, err
+		}
+		log.Details = details.String
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+func (r *sqlLogRepository) Create(log *TaskLog) error {
+	var details interface{}
+	if log.Details != "" {
+		details = log.Details
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	id, err := r.db.Insert(`
+		INSERT INTO task_logs (task_id, level, message, details, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, log.TaskID, log.Level, log.Message, details, log.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	log.ID = int(id)
+	return nil
+}
+
+func (r *sqlLogRepository) CreateBatch(logs []TaskLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(logs))
+	args := make([]interface{}, 0, len(logs)*5)
+	for _, log := range logs {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?)")
+
+		var details interface{}
+		if log.Details != "" {
+			details = log.Details
+		}
+		createdAt := log.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		args = append(args, log.TaskID, log.Level, log.Message, details, createdAt)
+	}
+
+	_, err := r.db.Exec("INSERT INTO task_logs (task_id, level, message, details, created_at) VALUES "+
+		strings.Join(placeholders, ", "), args...)
+	return err
+}
+
+// sqlConfigRepository 基于数据库的系统配置存取
+type sqlConfigRepository struct {
+	db *database.DB
+}
+
+func (r *sqlConfigRepository) Get(key string) (string, error) {
+	var value sql.NullString
+	err := r.db.QueryRow("SELECT config_value FROM system_config WHERE config_key = ?", key).Scan(&value)
+	return value.String, notFound(err)
+}
+
+func (r *sqlConfigRepository) Save(key, value, description string) error {
+	query := r.db.Dialect.Upsert("system_config",
+		[]string{"config_key", "config_value", "description", "updated_at"},
+		[]string{"config_key"},
+		[]string{"config_value", "updated_at"})
+	_, err := r.db.Exec(query, key, value, description, time.Now())
+	return err
+}
@@ -0,0 +1,1047 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/m/v2/pkg/constants"
+)
+
+// memoryStore 内存存储，各内存存取接口共享，以便关联查询站点名称和级联删除
+type memoryStore struct {
+	mu        sync.RWMutex
+	sites     map[int]*Site
+	schedules map[int]Schedule
+	tasks     map[int]*Task
+	items     map[int]*Item
+	logs      []TaskLog
+	config    map[string]string
+	users     map[int]*User
+	sessions  map[string]*Session
+	apiKeys   map[int]*APIKey
+	audit     []AuditEntry
+	nextID    map[string]int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		sites:     make(map[int]*Site),
+		schedules: make(map[int]Schedule),
+		tasks:     make(map[int]*Task),
+		items:     make(map[int]*Item),
+		config:    make(map[string]string),
+		users:     make(map[int]*User),
+		sessions:  make(map[string]*Session),
+		apiKeys:   make(map[int]*APIKey),
+		nextID:    make(map[string]int),
+	}
+}
+
+// newID 分配表内自增ID
+func (s *memoryStore) newID(table string) int {
+	s.nextID[table]++
+	return s.nextID[table]
+}
+
+// siteName 返回站点名称，站点不存在时为空
+func (s *memoryStore) siteName(id int) string {
+	if site, ok := s.sites[id]; ok {
+		return site.Name
+	}
+	return ""
+}
+
+// newerFirst 按时间倒序、时间相同时按ID倒序比较
+func newerFirst(ti, tj time.Time, idi, idj int) bool {
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+	return idi > idj
+}
+
+// memorySiteRepository 基于内存的站点存取
+type memorySiteRepository struct {
+	store *memoryStore
+}
+
+func (r *memorySiteRepository) match(site *Site, filter SiteFilter) bool {
+	return filter.Enabled == nil || site.Enabled == *filter.Enabled
+}
+
+func (r *memorySiteRepository) List(filter SiteFilter) ([]Site, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var sites []Site
+	for _, site := range r.store.sites {
+		if r.match(site, filter) {
+			sites = append(sites, *site)
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		return newerFirst(sites[i].CreatedAt, sites[j].CreatedAt, sites[i].ID, sites[j].ID)
+	})
+
+	start, end := filter.slice(len(sites))
+	return sites[start:end], len(sites), nil
+}
+
+func (r *memorySiteRepository) Count(filter SiteFilter) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	count := 0
+	for _, site := range r.store.sites {
+		if r.match(site, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memorySiteRepository) Get(id int) (*Site, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	site, ok := r.store.sites[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *site
+	return &copied, nil
+}
+
+func (r *memorySiteRepository) GetByName(name string) (*Site, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, site := range r.store.sites {
+		if site.Name == name {
+			copied := *site
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memorySiteRepository) ExistsByName(name string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, site := range r.store.sites {
+		if site.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memorySiteRepository) Create(site *Site) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	site.ID = r.store.newID("sites")
+	site.Status = "ready"
+	site.CreatedAt = now
+	site.UpdatedAt = now
+
+	copied := *site
+	r.store.sites[site.ID] = &copied
+	return nil
+}
+
+func (r *memorySiteRepository) Update(site *Site) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.sites[site.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = site.Name
+	existing.BaseURL = site.BaseURL
+	existing.Description = site.Description
+	existing.StartURLs = site.StartURLs
+	existing.Selectors = site.Selectors
+	existing.Rules = site.Rules
+	existing.Enabled = site.Enabled
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memorySiteRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.sites[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.sites, id)
+	delete(r.store.schedules, id)
+
+	// 与外键约束一致，级联删除站点的任务和数据
+	for taskID, task := range r.store.tasks {
+		if task.SiteID == id {
+			r.store.deleteTask(taskID)
+		}
+	}
+	for itemID, item := range r.store.items {
+		if item.SiteID == id {
+			delete(r.store.items, itemID)
+		}
+	}
+	return nil
+}
+
+func (r *memorySiteRepository) SetEnabled(id int, enabled bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	site, ok := r.store.sites[id]
+	if !ok {
+		return ErrNotFound
+	}
+	site.Enabled = enabled
+	site.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memorySiteRepository) GetSchedule(id int) (*Schedule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	site, ok := r.store.sites[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	schedule, ok := r.store.schedules[id]
+	if !ok {
+		schedule = Schedule{SiteID: id}
+	}
+	schedule.SiteName = site.Name
+	schedule.LastRunAt = site.LastRunAt
+	return &schedule, nil
+}
+
+func (r *memorySiteRepository) UpdateSchedule(schedule *Schedule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	site, ok := r.store.sites[schedule.SiteID]
+	if !ok {
+		return ErrNotFound
+	}
+	r.store.schedules[schedule.SiteID] = *schedule
+	site.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memorySiteRepository) ListScheduled() ([]Schedule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var schedules []Schedule
+	for id, schedule := range r.store.schedules {
+		site, ok := r.store.sites[id]
+		if !ok || !site.Enabled || !schedule.Enabled || schedule.Schedule == "" {
+			continue
+		}
+		schedule.SiteName = site.Name
+		schedule.LastRunAt = site.LastRunAt
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].SiteID < schedules[j].SiteID })
+	return schedules, nil
+}
+
+func (r *memorySiteRepository) SetNextRun(id int, next time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.sites[id]; !ok {
+		return ErrNotFound
+	}
+	schedule := r.store.schedules[id]
+	schedule.SiteID = id
+	schedule.NextRunAt = &next
+	r.store.schedules[id] = schedule
+	return nil
+}
+
+func (r *memorySiteRepository) SetRunning(id int, running bool, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	site, ok := r.store.sites[id]
+	if !ok {
+		return ErrNotFound
+	}
+	site.Status = "ready"
+	if running {
+		site.Status = "running"
+		site.LastRunAt = &at
+	}
+	return nil
+}
+
+func (r *memorySiteRepository) ResetRunning() error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, site := range r.store.sites {
+		if site.Status == "running" {
+			site.Status = "ready"
+		}
+	}
+	return nil
+}
+
+// memoryTaskRepository 基于内存的任务存取
+type memoryTaskRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryTaskRepository) match(task *Task, filter TaskFilter) bool {
+	if filter.Status != "" && task.Status != filter.Status {
+		return false
+	}
+	if len(filter.SiteIDs) > 0 && !containsInt(filter.SiteIDs, task.SiteID) {
+		return false
+	}
+	return filter.SiteID <= 0 || task.SiteID == filter.SiteID
+}
+
+// snapshot 返回任务副本，并补充站点名称和进度
+func (r *memoryTaskRepository) snapshot(task *Task) Task {
+	copied := *task
+	copied.SiteName = r.store.siteName(task.SiteID)
+	copied.computeProgress()
+	return copied
+}
+
+func (r *memoryTaskRepository) List(filter TaskFilter) ([]Task, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var tasks []Task
+	for _, task := range r.store.tasks {
+		if r.match(task, filter) {
+			tasks = append(tasks, r.snapshot(task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return newerFirst(tasks[i].CreatedAt, tasks[j].CreatedAt, tasks[i].ID, tasks[j].ID)
+	})
+
+	start, end := filter.slice(len(tasks))
+	return tasks[start:end], len(tasks), nil
+}
+
+func (r *memoryTaskRepository) Count(filter TaskFilter) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	count := 0
+	for _, task := range r.store.tasks {
+		if r.match(task, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryTaskRepository) Get(id int) (*Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	task, ok := r.store.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	snapshot := r.snapshot(task)
+	return &snapshot, nil
+}
+
+func (r *memoryTaskRepository) Create(task *Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	task.ID = r.store.newID("tasks")
+	task.Status = constants.SpiderStatusPending
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	copied := *task
+	r.store.tasks[task.ID] = &copied
+	return nil
+}
+
+func (r *memoryTaskRepository) Update(task *Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.tasks[task.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = task.Name
+	existing.SiteID = task.SiteID
+	existing.Config = task.Config
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memoryTaskRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.tasks[id]; !ok {
+		return ErrNotFound
+	}
+	r.store.deleteTask(id)
+	return nil
+}
+
+func (r *memoryTaskRepository) MarkRunning(id int, at time.Time) error {
+	return r.modify(id, func(task *Task) {
+		task.Status = constants.SpiderStatusRunning
+		task.StartTime = &at
+		task.EndTime = nil
+		task.ErrorMessage = ""
+		setTaskStats(task, TaskStats{})
+	})
+}
+
+func (r *memoryTaskRepository) UpdateStats(id int, stats TaskStats) error {
+	return r.modify(id, func(task *Task) {
+		setTaskStats(task, stats)
+	})
+}
+
+func (r *memoryTaskRepository) Finish(id int, status, errMsg string, stats TaskStats, at time.Time) error {
+	return r.modify(id, func(task *Task) {
+		task.Status = status
+		task.EndTime = &at
+		task.ErrorMessage = errMsg
+		setTaskStats(task, stats)
+	})
+}
+
+func (r *memoryTaskRepository) FailRunning(errMsg string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, task := range r.store.tasks {
+		if task.Status == constants.SpiderStatusRunning {
+			end := at
+			task.Status = constants.SpiderStatusFailed
+			task.EndTime = &end
+			task.ErrorMessage = errMsg
+			task.UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// modify 在写锁内修改任务并更新修改时间
+func (r *memoryTaskRepository) modify(id int, fn func(task *Task)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	task, ok := r.store.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(task)
+	task.UpdatedAt = time.Now()
+	return nil
+}
+
+// setTaskStats 写入任务统计字段
+func setTaskStats(task *Task, stats TaskStats) {
+	task.TotalURLs = stats.TotalURLs
+	task.ProcessedURLs = stats.ProcessedURLs
+	task.SuccessURLs = stats.SuccessURLs
+	task.FailedURLs = stats.FailedURLs
+	task.SkippedURLs = stats.SkippedURLs
+	task.ItemsCount = stats.ItemsCount
+}
+
+// deleteTask 删除任务及其日志，并解除数据与任务的关联，调用方需持有写锁
+func (s *memoryStore) deleteTask(id int) {
+	delete(s.tasks, id)
+
+	logs := s.logs[:0]
+	for _, log := range s.logs {
+		if log.TaskID != id {
+			logs = append(logs, log)
+		}
+	}
+	s.logs = logs
+
+	for _, item := range s.items {
+		if item.TaskID != nil && *item.TaskID == id {
+			item.TaskID = nil
+		}
+	}
+}
+
+// memoryItemRepository 基于内存的数据存取
+type memoryItemRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryItemRepository) match(item *Item, filter ItemFilter) bool {
+	if filter.SiteID > 0 && item.SiteID != filter.SiteID {
+		return false
+	}
+	if len(filter.SiteIDs) > 0 && !containsInt(filter.SiteIDs, item.SiteID) {
+		return false
+	}
+	if filter.Status != "" && item.Status != filter.Status {
+		return false
+	}
+	if filter.ChangeStatus != "" && item.ChangeStatus != filter.ChangeStatus {
+		return false
+	}
+	for _, keyword := range []string{filter.Keyword, filter.Search} {
+		if keyword != "" && !containsFold(item.Title, keyword) && !containsFold(item.Content, keyword) {
+			return false
+		}
+	}
+	if filter.StartDate != "" && item.CrawlTime.Format(time.DateTime) < filter.StartDate {
+		return false
+	}
+	if filter.EndDate != "" && item.CrawlTime.Format(time.DateTime) > filter.EndDate {
+		return false
+	}
+	return true
+}
+
+func (r *memoryItemRepository) List(filter ItemFilter) ([]Item, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var items []Item
+	for _, item := range r.store.items {
+		if !r.match(item, filter) {
+			continue
+		}
+		copied := *item
+		copied.SiteName = r.store.siteName(item.SiteID)
+		if !filter.WithContent {
+			copied.Content = ""
+		}
+		items = append(items, copied)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return newerFirst(items[i].CrawlTime, items[j].CrawlTime, items[i].ID, items[j].ID)
+	})
+
+	start, end := filter.slice(len(items))
+	return items[start:end], len(items), nil
+}
+
+func (r *memoryItemRepository) Each(filter ItemFilter, fn func(item *Item) error) error {
+	items, _, err := r.List(filter)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err := fn(&items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryItemRepository) Get(id int) (*Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	item, ok := r.store.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *item
+	copied.SiteName = r.store.siteName(item.SiteID)
+	return &copied, nil
+}
+
+func (r *memoryItemRepository) Save(item *Item) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if item.Status == "" {
+		item.Status = "new"
+	}
+	if item.CrawlTime.IsZero() {
+		item.CrawlTime = time.Now()
+	}
+
+	item.ID = 0
+	for id, existing := range r.store.items {
+		if existing.URL == item.URL && existing.SiteID == item.SiteID {
+			item.ID = id
+			break
+		}
+	}
+	created := item.ID == 0
+	oldHash := ""
+	if created {
+		item.ID = r.store.newID("items")
+	} else {
+		oldHash = r.store.items[item.ID].ContentHash
+	}
+	item.ContentHash, _ = item.fingerprint()
+	item.ChangeStatus = changeStatus(created, oldHash, item.ContentHash)
+	checkedAt := time.Now()
+	item.CheckedAt = &checkedAt
+
+	// 与数据库实现一致，空的列表字段保存为 [] 而不是 null
+	copied := *item
+	copied.Keywords = nonNil(copied.Keywords)
+	copied.Tags = nonNil(copied.Tags)
+	copied.Links = nonNil(copied.Links)
+	copied.Images = nonNil(copied.Images)
+	copied.Videos = nonNil(copied.Videos)
+	if copied.Metadata == nil {
+		copied.Metadata = map[string]interface{}{}
+	}
+	r.store.items[item.ID] = &copied
+	return created, nil
+}
+
+func (r *memoryItemRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.items[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.items, id)
+	return nil
+}
+
+func (r *memoryItemRepository) Stats() (*ItemStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	weekAgo := now.AddDate(0, 0, -7).Format(time.DateOnly)
+
+	stats := &ItemStats{Total: len(r.store.items)}
+	bySite := make(map[int]int)
+	byDate := make(map[string]int)
+	for _, item := range r.store.items {
+		bySite[item.SiteID]++
+		date := item.CrawlTime.Local().Format(time.DateOnly)
+		if date == today {
+			stats.Today++
+		}
+		if date >= weekAgo {
+			byDate[date]++
+		}
+	}
+
+	for id, site := range r.store.sites {
+		stats.BySite = append(stats.BySite, SiteCount{Name: site.Name, Count: bySite[id]})
+	}
+	sort.Slice(stats.BySite, func(i, j int) bool {
+		return stats.BySite[i].Count > stats.BySite[j].Count
+	})
+	if len(stats.BySite) > 10 {
+		stats.BySite = stats.BySite[:10]
+	}
+
+	for date, count := range byDate {
+		stats.Recent = append(stats.Recent, DateCount{Date: date, Count: count})
+	}
+	sort.Slice(stats.Recent, func(i, j int) bool {
+		return stats.Recent[i].Date > stats.Recent[j].Date
+	})
+
+	return stats, nil
+}
+
+// containsFold 不区分大小写判断是否包含子串
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// memoryLogRepository 基于内存的任务日志存取
+type memoryLogRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryLogRepository) List(filter LogFilter) ([]TaskLog, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var logs []TaskLog
+	for _, log := range r.store.logs {
+		if filter.TaskID > 0 && log.TaskID != filter.TaskID {
+			continue
+		}
+		if filter.Level != "" && log.Level != filter.Level {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		return newerFirst(logs[i].CreatedAt, logs[j].CreatedAt, logs[i].ID, logs[j].ID)
+	})
+
+	start, end := filter.slice(len(logs))
+	return logs[start:end], nil
+}
+
+func (r *memoryLogRepository) Create(log *TaskLog) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	log.ID = r.store.newID("task_logs")
+	r.store.logs = append(r.store.logs, *log)
+	return nil
+}
+
+func (r *memoryLogRepository) CreateBatch(logs []TaskLog) error {
+	for i := range logs {
+		log := logs[i]
+		if err := r.Create(&log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryConfigRepository 基于内存的系统配置存取
+type memoryConfigRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryConfigRepository) Get(key string) (string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	value, ok := r.store.config[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (r *memoryConfigRepository) Save(key, value, description string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.config[key] = value
+	return nil
+}
+
+// memoryUserRepository 基于内存的用户存取
+type memoryUserRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryUserRepository) Count() (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return len(r.store.users), nil
+}
+
+func (r *memoryUserRepository) List() ([]User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var users []User
+	for _, user := range r.store.users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *memoryUserRepository) Get(id int) (*User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepository) GetByUsername(username string) (*User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Create(user *User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.Username == user.Username {
+			return errors.New("用户名已存在")
+		}
+	}
+	if user.Role == "" {
+		user.Role = "viewer"
+	}
+	now := time.Now()
+	user.ID = r.store.newID("users")
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	copied := *user
+	r.store.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepository) Update(user *User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.PasswordHash = user.PasswordHash
+	existing.Role = user.Role
+	existing.Enabled = user.Enabled
+	existing.UpdatedAt = time.Now()
+	user.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *memoryUserRepository) TouchLogin(id int, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.LastLoginAt = &at
+	return nil
+}
+
+// memorySessionRepository 基于内存的会话存取
+type memorySessionRepository struct {
+	store *memoryStore
+}
+
+func (r *memorySessionRepository) Create(session *Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	copied := *session
+	r.store.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *memorySessionRepository) Get(id string) (*Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *memorySessionRepository) Revoke(id string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return ErrNotFound
+	}
+	session.RevokedAt = &at
+	return nil
+}
+
+func (r *memorySessionRepository) RevokeUser(userID int, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, session := range r.store.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			revokedAt := at
+			session.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) DeleteExpired(before time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	count := 0
+	for id, session := range r.store.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.store.sessions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// memoryAPIKeyRepository 基于内存的API密钥存取
+type memoryAPIKeyRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAPIKeyRepository) List() ([]APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var keys []APIKey
+	for _, key := range r.store.apiKeys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) Get(id int) (*APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	key, ok := r.store.apiKeys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (r *memoryAPIKeyRepository) GetByHash(hash string) (*APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, key := range r.store.apiKeys {
+		if key.KeyHash == hash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAPIKeyRepository) Create(key *APIKey) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key.ID = r.store.newID("api_keys")
+	key.CreatedAt = time.Now()
+	copied := *key
+	r.store.apiKeys[key.ID] = &copied
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.apiKeys[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.store.apiKeys, id)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) TouchUsed(id int, at time.Time, ip string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key, ok := r.store.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	key.LastUsedIP = ip
+	return nil
+}
+
+// memoryAuditRepository 基于内存的审计记录存取
+type memoryAuditRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryAuditRepository) match(entry *AuditEntry, filter AuditFilter) bool {
+	for _, cond := range [][2]string{
+		{filter.ActorType, entry.ActorType},
+		{filter.Actor, entry.ActorName},
+		{filter.Action, entry.Action},
+		{filter.TargetType, entry.TargetType},
+		{filter.TargetID, entry.TargetID},
+	} {
+		if cond[0] != "" && cond[0] != cond[1] {
+			return false
+		}
+	}
+	if filter.StartDate != "" && entry.CreatedAt.Format(time.DateTime) < filter.StartDate {
+		return false
+	}
+	if filter.EndDate != "" && entry.CreatedAt.Format(time.DateTime) > filter.EndDate {
+		return false
+	}
+	return true
+}
+
+func (r *memoryAuditRepository) List(filter AuditFilter) ([]AuditEntry, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entries []AuditEntry
+	for i := range r.store.audit {
+		if r.match(&r.store.audit[i], filter) {
+			entries = append(entries, r.store.audit[i])
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return newerFirst(entries[i].CreatedAt, entries[j].CreatedAt, entries[i].ID, entries[j].ID)
+	})
+
+	start, end := filter.slice(len(entries))
+	return entries[start:end], len(entries), nil
+}
+
+func (r *memoryAuditRepository) Create(entry *AuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry.ID = r.store.newID("audit_log")
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	r.store.audit = append(r.store.audit, *entry)
+	return nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// Repositories 各类数据的存取接口集合，控制器只依赖这些接口
+type Repositories struct {
+	Sites    SiteRepository
+	Tasks    TaskRepository
+	Items    ItemRepository
+	Logs     LogRepository
+	Config   ConfigRepository
+	Users    UserRepository
+	Sessions SessionRepository
+	APIKeys  APIKeyRepository
+	Audit    AuditRepository
+
+	// Ping 检查底层存储是否可用
+	Ping func() error
+}
+
+// NewSQLRepositories 创建基于数据库的存取接口
+func NewSQLRepositories(db *database.DB) *Repositories {
+	return &Repositories{
+		Sites:    &sqlSiteRepository{db: db},
+		Tasks:    &sqlTaskRepository{db: db},
+		Items:    &sqlItemRepository{db: db},
+		Logs:     &sqlLogRepository{db: db},
+		Config:   &sqlConfigRepository{db: db},
+		Users:    &sqlUserRepository{db: db},
+		Sessions: &sqlSessionRepository{db: db},
+		APIKeys:  &sqlAPIKeyRepository{db: db},
+		Audit:    &sqlAuditRepository{db: db},
+		Ping:     db.Ping,
+	}
+}
+
+// NewMemoryRepositories 创建基于内存的存取接口，数据不持久化，用于测试和本地调试
+func NewMemoryRepositories() *Repositories {
+	store := newMemoryStore()
+	return &Repositories{
+		Sites:    &memorySiteRepository{store},
+		Tasks:    &memoryTaskRepository{store},
+		Items:    &memoryItemRepository{store},
+		Logs:     &memoryLogRepository{store},
+		Config:   &memoryConfigRepository{store},
+		Users:    &memoryUserRepository{store},
+		Sessions: &memorySessionRepository{store},
+		APIKeys:  &memoryAPIKeyRepository{store},
+		Audit:    &memoryAuditRepository{store},
+		Ping:     func() error { return nil },
+	}
+}
+
+// Pagination 分页参数，PageSize 为 0 时不分页
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// offset 返回分页偏移量
+func (p Pagination) offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// slice 返回切片中当前页的区间
+func (p Pagination) slice(total int) (int, int) {
+	if p.PageSize <= 0 {
+		return 0, total
+	}
+	start := p.offset()
+	if start > total {
+		start = total
+	}
+	end := start + p.PageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// limitSQL 返回分页子句及参数
+func (p Pagination) limitSQL() (string, []interface{}) {
+	if p.PageSize <= 0 {
+		return "", nil
+	}
+	return " LIMIT ? OFFSET ?", []interface{}{p.PageSize, p.offset()}
+}
+
+// scanner 可扫描一行数据的结果，*sql.Row 和 *sql.Rows 均满足
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// notFound 将 sql.ErrNoRows 转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// affected 检查语句是否影响了记录，未影响时返回 ErrNotFound
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// updated 检查更新语句的结果。MySQL 对未改变的行不计入影响行数，
+// 因此影响行数为 0 时再确认记录是否存在
+func updated(db *database.DB, table string, id int, result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return nil
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id = ?", id).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// inInts 构建 column IN (?, ...) 条件，ids 不能为空
+func inInts(column string, ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return column + " IN (?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+// containsInt 判断切片中是否包含指定值
+func containsInt(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeJSON 将字段编码为 JSON 字符串
+func encodeJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// decodeJSON 解析 JSON 字段，NULL 或空值时保持零值
+func decodeJSON(s sql.NullString, v interface{}) {
+	if s.Valid && s.String != "" && s.String != "null" {
+		json.Unmarshal([]byte(s.String), v)
+	}
+}
+
+// timePtr 将可空时间转换为指针
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
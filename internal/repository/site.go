@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// SiteRules 站点规则
+type SiteRules struct {
+	MaxDepth         int      `json:"max_depth"`
+	MaxPages         int      `json:"max_pages"`
+	URLPatterns      []string `json:"url_patterns"`
+	ExcludePatterns  []string `json:"exclude_patterns"`
+	ForbiddenDomains []string `json:"forbidden_domains"`
+	RespectRobots    *bool    `json:"respect_robots"`
+	ContentTypes     []string `json:"content_types"`
+	Concurrent       int      `json:"concurrent"`
+	Delay            int      `json:"delay"`
+}
+
+// Site 站点配置
+type Site struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	BaseURL     string            `json:"base_url"`
+	Description string            `json:"description"`
+	StartURLs   []string          `json:"start_urls"`
+	Selectors   map[string]string `json:"selectors"`
+	Rules       SiteRules         `json:"rules"`
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Status      string            `json:"status"`
+	LastRunAt   *time.Time        `json:"last_run_at"`
+}
+
+// Schedule 站点调度配置
+type Schedule struct {
+	SiteID    int        `json:"site_id"`
+	SiteName  string     `json:"site_name"`
+	Schedule  string     `json:"schedule"`
+	Enabled   bool       `json:"enabled"`
+	NextRunAt *time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+}
+
+// SiteFilter 站点查询条件
+type SiteFilter struct {
+	Enabled *bool
+	Pagination
+}
+
+// SiteRepository 站点存取接口
+type SiteRepository interface {
+	// List 按创建时间倒序返回当前页的站点及总数
+	List(filter SiteFilter) ([]Site, int, error)
+	// Count 返回满足条件的站点数
+	Count(filter SiteFilter) (int, error)
+	// Get 返回指定站点，不存在时返回 ErrNotFound
+	Get(id int) (*Site, error)
+	// GetByName 按名称返回站点，不存在时返回 ErrNotFound
+	GetByName(name string) (*Site, error)
+	// ExistsByName 判断站点名称是否已被使用
+	ExistsByName(name string) (bool, error)
+	// Create 创建站点并回填ID
+	Create(site *Site) error
+	// Update 更新站点的基本配置
+	Update(site *Site) error
+	// Delete 删除站点
+	Delete(id int) error
+	// SetEnabled 启用或禁用站点
+	SetEnabled(id int, enabled bool) error
+	// GetSchedule 返回站点调度配置
+	GetSchedule(id int) (*Schedule, error)
+	// UpdateSchedule 更新站点调度配置
+	UpdateSchedule(schedule *Schedule) error
+	// ListScheduled 返回已启用且设置了调度规则的站点的调度配置
+	ListScheduled() ([]Schedule, error)
+	// SetNextRun 记录站点下次运行时间
+	SetNextRun(id int, next time.Time) error
+	// SetRunning 更新站点运行状态，开始运行时记录最后运行时间
+	SetRunning(id int, running bool, at time.Time) error
+	// ResetRunning 将所有运行中的站点恢复为就绪，用于服务重启后恢复
+	ResetRunning() error
+}
+
+// sqlSiteRepository 基于数据库的站点存取
+type sqlSiteRepository struct {
+	db *database.DB
+}
+
+// siteColumns 站点查询字段，顺序与 scanSite 一致
+const siteColumns = `id, name, base_url, description, start_urls, selectors, rules,
+	enabled, created_at, updated_at, status, last_run_at`
+
+// scanSite 扫描一行站点数据
+func scanSite(row scanner) (*Site, error) {
+	var site Site
+	var description, startURLsJSON, selectorsJSON, rulesJSON sql.NullString
+	var lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&site.ID, &site.Name, &site.BaseURL, &description,
+		&startURLsJSON, &selectorsJSON, &rulesJSON,
+		&site.Enabled, &site.CreatedAt, &site.UpdatedAt,
+		&site.Status, &lastRunAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	site.Description = description.String
+	decodeJSON(startURLsJSON, &site.StartURLs)
+	decodeJSON(selectorsJSON, &site.Selectors)
+	decodeJSON(rulesJSON, &site.Rules)
+	site.LastRunAt = timePtr(lastRunAt)
+
+	return &site, nil
+}
+
+// where 构建站点查询条件
+func (f SiteFilter) where() (string, []interface{}) {
+	where := "1=1"
+	args := []interface{}{}
+
+	if f.Enabled != nil {
+		where += " AND enabled = ?"
+		args = append(args, *f.Enabled)
+	}
+	return where, args
+}
+
+func (r *sqlSiteRepository) List(filter SiteFilter) ([]Site, int, error) {
+	total, err := r.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := filter.where()
+	limit, limitArgs := filter.limitSQL()
+	rows, err := r.db.Query("SELECT "+siteColumns+" FROM sites WHERE "+where+" ORDER BY created_at DESC, id DESC"+limit,
+		append(args, limitArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var sites []Site
+	for rows.Next() {
+		site, err := scanSite(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		sites = append(sites, *site)
+	}
+	return sites, total, rows.Err()
+}
+
+func (r *sqlSiteRepository) Count(filter SiteFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM sites WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *sqlSiteRepository) Get(id int) (*Site, error) {
+	site, err := scanSite(r.db.QueryRow("SELECT "+siteColumns+" FROM sites WHERE id = ?", id))
+	return site, notFound(err)
+}
+
+func (r *sqlSiteRepository) GetByName(name string) (*Site, error) {
+	site, err := scanSite(r.db.QueryRow("SELECT "+siteColumns+" FROM sites WHERE name = ?", name))
+	return site, notFound(err)
+}
+
+func (r *sqlSiteRepository) ExistsByName(name string) (bool, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM sites WHERE name = ?", name).Scan(&count)
+	return count > 0, err
+}
+
+func (r *sqlSiteRepository) Create(site *Site) error {
+	id, err := r.db.Insert(`
+		INSERT INTO sites (name, base_url, description, start_urls, selectors, rules, enabled, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'ready', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, site.Name, site.BaseURL, site.Description, encodeJSON(site.StartURLs), encodeJSON(site.Selectors),
+		encodeJSON(site.Rules), site.Enabled)
+	if err != nil {
+		return err
+	}
+
+	site.ID = int(id)
+	site.Status = "ready"
+	return nil
+}
+
+func (r *sqlSiteRepository) Update(site *Site) error {
+	result, err := r.db.Exec(`
+		UPDATE sites
+		SET name = ?, base_url = ?, description = ?, start_urls = ?, selectors = ?, rules = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, site.Name, site.BaseURL, site.Description, encodeJSON(site.StartURLs), encodeJSON(site.Selectors),
+		encodeJSON(site.Rules), site.Enabled, site.ID)
+	return updated(r.db, "sites", site.ID, result, err)
+}
+
+func (r *sqlSiteRepository) Delete(id int) error {
+	return affected(r.db.Exec("DELETE FROM sites WHERE id = ?", id))
+}
+
+func (r *sqlSiteRepository) SetEnabled(id int, enabled bool) error {
+	result, err := r.db.Exec("UPDATE sites SET enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", enabled, id)
+	return updated(r.db, "sites", id, result, err)
+}
+
+// scheduleColumns 调度查询字段，顺序与 scanSchedule 一致
+const scheduleColumns = "id, name, schedule, schedule_enabled, next_run_at, last_run_at"
+
+// scanSchedule 扫描一行站点调度配置
+func scanSchedule(row scanner) (*Schedule, error) {
+	var schedule Schedule
+	var expr sql.NullString
+	var nextRunAt, lastRunAt sql.NullTime
+
+	err := row.Scan(&schedule.SiteID, &schedule.SiteName, &expr, &schedule.Enabled, &nextRunAt, &lastRunAt)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Schedule = expr.String
+	schedule.NextRunAt = timePtr(nextRunAt)
+	schedule.LastRunAt = timePtr(lastRunAt)
+	return &schedule, nil
+}
+
+func (r *sqlSiteRepository) GetSchedule(id int) (*Schedule, error) {
+	schedule, err := scanSchedule(r.db.QueryRow("SELECT "+scheduleColumns+" FROM sites WHERE id = ?", id))
+	return schedule, notFound(err)
+}
+
+func (r *sqlSiteRepository) UpdateSchedule(schedule *Schedule) error {
+	var nextRunAt interface{}
+	if schedule.NextRunAt != nil {
+		nextRunAt = *schedule.NextRunAt
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE sites SET schedule = ?, schedule_enabled = ?, next_run_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, schedule.Schedule, schedule.Enabled, nextRunAt, schedule.SiteID)
+	return updated(r.db, "sites", schedule.SiteID, result, err)
+}
+
+func (r *sqlSiteRepository) ListScheduled() ([]Schedule, error) {
+	rows, err := r.db.Query("SELECT " + scheduleColumns + `
+		FROM sites
+		WHERE enabled = TRUE AND schedule_enabled = TRUE AND schedule IS NOT NULL AND schedule <> ''
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *sqlSiteRepository) SetNextRun(id int, next time.Time) error {
+	result, err := r.db.Exec("UPDATE sites SET next_run_at = ? WHERE id = ?", next, id)
+	return updated(r.db, "sites", id, result, err)
+}
+
+func (r *sqlSiteRepository) SetRunning(id int, running bool, at time.Time) error {
+	var result sql.Result
+	var err error
+	if running {
+		result, err = r.db.Exec("UPDATE sites SET status = 'running', last_run_at = ? WHERE id = ?", at, id)
+	} else {
+		result, err = r.db.Exec("UPDATE sites SET status = 'ready' WHERE id = ?", id)
+	}
+	return updated(r.db, "sites", id, result, err)
+}
+
+func (r *sqlSiteRepository) ResetRunning() error {
+	_, err := r.db.Exec("UPDATE sites SET status = 'ready' WHERE status = 'running'")
+	return err
+}
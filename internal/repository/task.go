@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/pkg/constants"
+)
+
+// Task 爬虫任务
+type Task struct {
+	ID            int                    `json:"id"`
+	Name          string                 `json:"name"`
+	SiteID        int                    `json:"site_id"`
+	SiteName      string                 `json:"site_name"`
+	Status        string                 `json:"status"`
+	Config        map[string]interface{} `json:"config"`
+	StartTime     *time.Time             `json:"start_time"`
+	EndTime       *time.Time             `json:"end_time"`
+	TotalURLs     int                    `json:"total_urls"`
+	ProcessedURLs int                    `json:"processed_urls"`
+	SuccessURLs   int                    `json:"success_urls"`
+	FailedURLs    int                    `json:"failed_urls"`
+	SkippedURLs   int                    `json:"skipped_urls"`
+	ItemsCount    int                    `json:"items_count"`
+	ErrorMessage  string                 `json:"error_message"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Duration      int64                  `json:"duration"` // 持续时间（秒）
+	Progress      float64                `json:"progress"` // 进度百分比
+}
+
+// computeProgress 计算持续时间和进度
+func (t *Task) computeProgress() {
+	t.Duration = 0
+	if t.StartTime != nil {
+		if t.EndTime != nil {
+			t.Duration = int64(t.EndTime.Sub(*t.StartTime).Seconds())
+		} else {
+			t.Duration = int64(time.Since(*t.StartTime).Seconds())
+		}
+	}
+
+	t.Progress = 0
+	if t.TotalURLs > 0 {
+		t.Progress = float64(t.ProcessedURLs) / float64(t.TotalURLs) * 100
+	}
+}
+
+// TaskStats 任务运行统计
+type TaskStats struct {
+	TotalURLs     int
+	ProcessedURLs int
+	SuccessURLs   int
+	FailedURLs    int
+	SkippedURLs   int
+	ItemsCount    int
+}
+
+// TaskFilter 任务查询条件
+type TaskFilter struct {
+	Status  string
+	SiteID  int
+	SiteIDs []int // 限定在这些站点内，为空时不限制
+	Pagination
+}
+
+// TaskRepository 任务存取接口
+type TaskRepository interface {
+	// List 按创建时间倒序返回当前页的任务及总数
+	List(filter TaskFilter) ([]Task, int, error)
+	// Count 返回满足条件的任务数
+	Count(filter TaskFilter) (int, error)
+	// Get 返回指定任务，不存在时返回 ErrNotFound
+	Get(id int) (*Task, error)
+	// Create 创建待运行的任务并回填ID
+	Create(task *Task) error
+	// Update 更新任务名称、站点和配置
+	Update(task *Task) error
+	// Delete 删除任务
+	Delete(id int) error
+	// MarkRunning 将任务标记为运行中，记录开始时间并清空上次运行的结果
+	MarkRunning(id int, at time.Time) error
+	// UpdateStats 写回运行中任务的统计
+	UpdateStats(id int, stats TaskStats) error
+	// Finish 写回任务的最终状态和统计，errMsg 为空时清空错误信息
+	Finish(id int, status, errMsg string, stats TaskStats, at time.Time) error
+	// FailRunning 将所有运行中的任务标记为失败，用于服务重启后恢复
+	FailRunning(errMsg string, at time.Time) error
+}
+
+// sqlTaskRepository 基于数据库的任务存取
+type sqlTaskRepository struct {
+	db *database.DB
+}
+
+// taskColumns 任务查询字段，顺序与 scanTask 一致
+const taskColumns = `t.id, t.name, t.site_id, s.name, t.status, t.config,
+	t.start_time, t.end_time, t.total_urls, t.processed_urls,
+	t.success_urls, t.failed_urls, t.skipped_urls, t.items_count, t.error_message,
+	t.created_at, t.updated_at`
+
+// scanTask 扫描一行任务数据
+func scanTask(row scanner) (*Task, error) {
+	var task Task
+	var siteName, configJSON, errorMessage sql.NullString
+	var startTime, endTime sql.NullTime
+
+	err := row.Scan(
+		&task.ID, &task.Name, &task.SiteID, &siteName, &task.Status,
+		&configJSON, &startTime, &endTime, &task.TotalURLs,
+		&task.ProcessedURLs, &task.SuccessURLs, &task.FailedURLs, &task.SkippedURLs,
+		&task.ItemsCount, &errorMessage, &task.CreatedAt, &task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	task.SiteName = siteName.String
+	task.ErrorMessage = errorMessage.String
+	decodeJSON(configJSON, &task.Config)
+	task.StartTime = timePtr(startTime)
+	task.EndTime = timePtr(endTime)
+	task.computeProgress()
+
+	return &task, nil
+}
+
+// where 构建任务查询条件
+func (f TaskFilter) where() (string, []interface{}) {
+	where := "1=1"
+	args := []interface{}{}
+
+	if f.Status != "" {
+		where += " AND t.status = ?"
+		args = append(args, f.Status)
+	}
+	if f.SiteID > 0 {
+		where += " AND t.site_id = ?"
+		args = append(args, f.SiteID)
+	}
+	if len(f.SiteIDs) > 0 {
+		cond, idArgs := inInts("t.site_id", f.SiteIDs)
+		where += " AND " + cond
+		args = append(args, idArgs...)
+	}
+	return where, args
+}
+
+func (r *sqlTaskRepository) List(filter TaskFilter) ([]Task, int, error) {
+	total, err := r.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where, args := filter.where()
+	limit, limitArgs := filter.limitSQL()
+	query := `SELECT ` + taskColumns + `
+		FROM tasks t
+		LEFT JOIN sites s ON t.site_id = s.id
+		WHERE ` + where + `
+		ORDER BY t.created_at DESC, t.id DESC` + limit
+
+	rows, err := r.db.Query(query, append(args, limitArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tasks = append(tasks, *task)
+	}
+	return tasks, total, rows.Err()
+}
+
+func (r *sqlTaskRepository) Count(filter TaskFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM tasks t WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *sqlTaskRepository) Get(id int) (*Task, error) {
+	task, err := scanTask(r.db.QueryRow(`SELECT `+taskColumns+`
+		FROM tasks t
+		LEFT JOIN sites s ON t.site_id = s.id
+		WHERE t.id = ?`, id))
+	return task, notFound(err)
+}
+
+func (r *sqlTaskRepository) Create(task *Task) error {
+	id, err := r.db.Insert(`
+		INSERT INTO tasks (name, site_id, config, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, task.Name, task.SiteID, encodeJSON(task.Config), constants.SpiderStatusPending)
+	if err != nil {
+		return err
+	}
+
+	task.ID = int(id)
+	task.Status = constants.SpiderStatusPending
+	return nil
+}
+
+func (r *sqlTaskRepository) Update(task *Task) error {
+	result, err := r.db.Exec(`
+		UPDATE tasks
+		SET name = ?, site_id = ?, config = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, task.Name, task.SiteID, encodeJSON(task.Config), task.ID)
+	return updated(r.db, "tasks", task.ID, result, err)
+}
+
+func (r *sqlTaskRepository) Delete(id int) error {
+	return affected(r.db.Exec("DELETE FROM tasks WHERE id = ?", id))
+}
+
+func (r *sqlTaskRepository) MarkRunning(id int, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE tasks
+		SET status = ?, start_time = ?, end_time = NULL, error_message = NULL,
+		    total_urls = 0, processed_urls = 0, success_urls = 0, failed_urls = 0, skipped_urls = 0, items_count = 0,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, constants.SpiderStatusRunning, at, id)
+	return updated(r.db, "tasks", id, result, err)
+}
+
+func (r *sqlTaskRepository) UpdateStats(id int, stats TaskStats) error {
+	result, err := r.db.Exec(`
+		UPDATE tasks
+		SET total_urls = ?, processed_urls = ?, success_urls = ?, failed_urls = ?, skipped_urls = ?, items_count = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, stats.TotalURLs, stats.ProcessedURLs, stats.SuccessURLs, stats.FailedURLs, stats.SkippedURLs, stats.ItemsCount, id)
+	return updated(r.db, "tasks", id, result, err)
+}
+
+func (r *sqlTaskRepository) Finish(id int, status, errMsg string, stats TaskStats, at time.Time) error {
+	var message interface{}
+	if errMsg != "" {
+		message = errMsg
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE tasks
+		SET status = ?, end_time = ?, error_message = ?,
+		    total_urls = ?, processed_urls = ?, success_urls = ?, failed_urls = ?, skipped_urls = ?, items_count = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, at, message, stats.TotalURLs, stats.ProcessedURLs, stats.SuccessURLs, stats.FailedURLs, stats.SkippedURLs,
+		stats.ItemsCount, id)
+	return updated(r.db, "tasks", id, result, err)
+}
+
+func (r *sqlTaskRepository) FailRunning(errMsg string, at time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE tasks SET status = ?, end_time = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE status = ?
+	`, constants.SpiderStatusFailed, at, errMsg, constants.SpiderStatusRunning)
+	return err
+}
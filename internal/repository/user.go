@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"example.com/m/v2/internal/database"
+)
+
+// User 用户账号
+type User struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`
+	Enabled      bool       `json:"enabled"`
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Session 登录会话，访问令牌和刷新令牌通过会话ID关联，注销后两者均失效
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    int        `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active 会话是否未注销且未过期
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// UserRepository 用户存取接口
+type UserRepository interface {
+	// Count 返回用户总数
+	Count() (int, error)
+	// List 按ID升序返回全部用户
+	List() ([]User, error)
+	// Get 返回指定用户，不存在时返回 ErrNotFound
+	Get(id int) (*User, error)
+	// GetByUsername 按用户名返回用户，不存在时返回 ErrNotFound
+	GetByUsername(username string) (*User, error)
+	// Create 创建用户并回填ID，未指定角色时为 viewer
+	Create(user *User) error
+	// Update 更新用户的密码哈希、角色和启用状态
+	Update(user *User) error
+	// TouchLogin 记录登录时间
+	TouchLogin(id int, at time.Time) error
+}
+
+// SessionRepository 登录会话存取接口
+type SessionRepository interface {
+	// Create 创建会话
+	Create(session *Session) error
+	// Get 返回指定会话，不存在时返回 ErrNotFound
+	Get(id string) (*Session, error)
+	// Revoke 注销会话
+	Revoke(id string, at time.Time) error
+	// RevokeUser 注销用户的全部会话
+	RevokeUser(userID int, at time.Time) error
+	// DeleteExpired 删除已过期的会话，返回删除数量
+	DeleteExpired(before time.Time) (int, error)
+}
+
+// sqlUserRepository 基于数据库的用户存取
+type sqlUserRepository struct {
+	db *database.DB
+}
+
+const selectUsers = "SELECT id, username, password_hash, role, enabled, last_login_at, created_at, updated_at FROM users"
+
+// scanUser 扫描一行用户数据
+func scanUser(row scanner) (*User, error) {
+	var user User
+	var lastLogin sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Enabled, &lastLogin,
+		&user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.LastLoginAt = timePtr(lastLogin)
+	return &user, nil
+}
+
+func (r *sqlUserRepository) Count() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+func (r *sqlUserRepository) List() ([]User, error) {
+	rows, err := r.db.Query(selectUsers + " ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func (r *sqlUserRepository) Get(id int) (*User, error) {
+	user, err := scanUser(r.db.QueryRow(selectUsers+" WHERE id = ?", id))
+	return user, notFound(err)
+}
+
+func (r *sqlUserRepository) GetByUsername(username string) (*User, error) {
+	user, err := scanUser(r.db.QueryRow(selectUsers+" WHERE username = ?", username))
+	return user, notFound(err)
+}
+
+func (r *sqlUserRepository) Create(user *User) error {
+	if user.Role == "" {
+		user.Role = "viewer"
+	}
+	now := time.Now()
+	id, err := r.db.Insert(`
+		INSERT INTO users (username, password_hash, role, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.Username, user.PasswordHash, user.Role, user.Enabled, now, now)
+	if err != nil {
+		return err
+	}
+
+	user.ID = int(id)
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *sqlUserRepository) Update(user *User) error {
+	now := time.Now()
+	result, err := r.db.Exec("UPDATE users SET password_hash = ?, role = ?, enabled = ?, updated_at = ? WHERE id = ?",
+		user.PasswordHash, user.Role, user.Enabled, now, user.ID)
+	if err := updated(r.db, "users", user.ID, result, err); err != nil {
+		return err
+	}
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *sqlUserRepository) TouchLogin(id int, at time.Time) error {
+	return affected(r.db.Exec("UPDATE users SET last_login_at = ? WHERE id = ?", at, id))
+}
+
+// sqlSessionRepository 基于数据库的会话存取
+type sqlSessionRepository struct {
+	db *database.DB
+}
+
+func (r *sqlSessionRepository) Create(session *Session) error {
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	_, err := r.db.Exec("INSERT INTO user_sessions (id, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		session.ID, session.UserID, session.ExpiresAt, session.CreatedAt)
+	return err
+}
+
+func (r *sqlSessionRepository) Get(id string) (*Session, error) {
+	var session Session
+	var revokedAt sql.NullTime
+	err := r.db.QueryRow("SELECT id, user_id, expires_at, revoked_at, created_at FROM user_sessions WHERE id = ?", id).
+		Scan(&session.ID, &session.UserID, &session.ExpiresAt, &revokedAt, &session.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	session.RevokedAt = timePtr(revokedAt)
+	return &session, nil
+}
+
+func (r *sqlSessionRepository) Revoke(id string, at time.Time) error {
+	return affected(r.db.Exec("UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", at, id))
+}
+
+func (r *sqlSessionRepository) RevokeUser(userID int, at time.Time) error {
+	_, err := r.db.Exec("UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", at, userID)
+	return err
+}
+
+func (r *sqlSessionRepository) DeleteExpired(before time.Time) (int, error) {
+	result, err := r.db.Exec("DELETE FROM user_sessions WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"example.com/m/v2/internal/crawler"
+)
+
+// 事件类型
+const (
+	EventStatus   = "status"   // 任务状态变化
+	EventProgress = "progress" // 任务执行进度
+)
+
+// eventBufferSize 每个订阅者的事件缓冲，缓冲满时丢弃新事件
+const eventBufferSize = 64
+
+// Event 任务事件，通过 SSE 推送给前端
+type Event struct {
+	Type   string         `json:"type"`
+	TaskID int            `json:"task_id"`
+	SiteID int            `json:"site_id,omitempty"`
+	Status string         `json:"status,omitempty"`
+	Stats  *crawler.Stats `json:"stats,omitempty"`
+	Rate   float64        `json:"rate"`            // 每秒处理的页面数
+	Error  string         `json:"error,omitempty"` // 任务失败原因
+	Time   time.Time      `json:"time"`
+}
+
+// Broker 事件分发器，将任务事件广播给订阅者
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+}
+
+// subscriber 事件订阅者，taskID 为 0 时订阅所有任务的状态变化
+type subscriber struct {
+	taskID int
+	ch     chan Event
+}
+
+// NewBroker 创建事件分发器
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe 订阅事件，taskID 大于 0 时接收该任务的全部事件，
+// 为 0 时接收所有任务的状态变化。返回的函数用于取消订阅。
+func (b *Broker) Subscribe(taskID int) (<-chan Event, func()) {
+	sub := &subscriber{
+		taskID: taskID,
+		ch:     make(chan Event, eventBufferSize),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// Publish 发布事件，订阅者处理不及时时丢弃，不阻塞任务执行
+func (b *Broker) Publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if sub.taskID == 0 && ev.Type != EventStatus {
+			continue
+		}
+		if sub.taskID != 0 && sub.taskID != ev.TaskID {
+			continue
+		}
+
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/crawler"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/storage"
+	"example.com/m/v2/internal/utils"
+	"example.com/m/v2/pkg/constants"
+	"example.com/m/v2/pkg/models"
+)
+
+const (
+	statsFlushInterval = 2 * time.Second // 运行中任务统计写回数据库的间隔
+	progressInterval   = time.Second     // 推送任务进度事件的间隔
+)
+
+var (
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = errors.New("任务不存在")
+	// ErrTaskRunning 任务已在运行中
+	ErrTaskRunning = errors.New("任务已在运行中")
+	// ErrTaskNotRunning 任务未在运行中
+	ErrTaskNotRunning = errors.New("任务未在运行中")
+	// ErrSiteDisabled 站点已禁用
+	ErrSiteDisabled = errors.New("站点不存在或已禁用")
+)
+
+// Runner 任务执行器，负责在后台驱动爬虫执行任务并维护任务和站点的运行状态
+type Runner struct {
+	repos  *repository.Repositories
+	config *config.Config
+	logger utils.Logger
+	events *Broker
+
+	mu   sync.Mutex
+	jobs map[int]*job
+}
+
+// job 一个正在执行的任务
+type job struct {
+	task    *models.CrawlTask
+	logger  *utils.TaskLogger
+	spider  *crawler.Spider
+	ctx     context.Context // 任务上下文，创建任务时即可取消，爬虫启动前停止也能生效
+	cancel  context.CancelFunc
+	stopped bool
+	done    chan struct{}
+}
+
+// NewRunner 创建任务执行器
+func NewRunner(repos *repository.Repositories, cfg *config.Config, logger utils.Logger) *Runner {
+	return &Runner{
+		repos:  repos,
+		config: cfg,
+		logger: logger,
+		events: NewBroker(),
+		jobs:   make(map[int]*job),
+	}
+}
+
+// Events 返回任务事件分发器
+func (r *Runner) Events() *Broker {
+	return r.events
+}
+
+// RecoverInterrupted 将上次进程退出时遗留为运行中的任务标记为失败
+func (r *Runner) RecoverInterrupted() error {
+	if err := r.repos.Tasks.FailRunning("服务重启，任务被中断", time.Now()); err != nil {
+		return fmt.Errorf("恢复中断任务失败: %w", err)
+	}
+	if err := r.repos.Sites.ResetRunning(); err != nil {
+		return fmt.Errorf("恢复站点状态失败: %w", err)
+	}
+	return nil
+}
+
+// Start 在后台启动指定任务
+func (r *Runner) Start(taskID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.jobs[taskID]; ok {
+		return ErrTaskRunning
+	}
+
+	task, err := r.loadTask(taskID)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.NewStorage(r.config)
+	if err != nil {
+		return fmt.Errorf("创建存储实例失败: %w", err)
+	}
+
+	now := time.Now()
+	if err := r.repos.Tasks.MarkRunning(taskID, now); err != nil {
+		store.Close()
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+	if err := r.repos.Sites.SetRunning(task.SiteID, true, now); err != nil {
+		r.logger.Error("更新站点状态失败", "site_id", task.SiteID, "error", err)
+	}
+
+	// 任务期间的爬虫日志同时写入 task_logs
+	taskLogger := utils.NewTaskLogger(r.repos.Logs, taskID, r.logger, utils.ParseLogLevel(r.config.Logging.Level))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		task:   task,
+		logger: taskLogger,
+		spider: crawler.NewSpider(r.config, store, taskLogger),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	r.jobs[taskID] = j
+
+	r.events.Publish(Event{Type: EventStatus, TaskID: taskID, SiteID: task.SiteID, Status: constants.SpiderStatusRunning})
+	go r.run(j, store)
+
+	taskLogger.Info("任务已启动", "site", task.Name, "start_urls", len(task.StartURLs))
+	return nil
+}
+
+// RunSite 为站点创建一个新任务并在后台启动，返回任务ID
+func (r *Runner) RunSite(siteID int, siteName string) (int, error) {
+	task := &repository.Task{
+		Name:   siteName + " " + time.Now().Format("2006-01-02 15:04:05"),
+		SiteID: siteID,
+		Config: map[string]interface{}{},
+	}
+	if err := r.repos.Tasks.Create(task); err != nil {
+		return 0, fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	if err := r.Start(task.ID); err != nil {
+		return task.ID, err
+	}
+	return task.ID, nil
+}
+
+// Stop 停止指定任务，已排队和进行中的请求会被中止，已抓取的统计保留
+func (r *Runner) Stop(taskID int) error {
+	r.mu.Lock()
+	j, ok := r.jobs[taskID]
+	if ok {
+		j.stopped = true
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrTaskNotRunning
+	}
+
+	j.cancel()
+
+	j.logger.Info("任务已请求停止")
+	return nil
+}
+
+// Shutdown 停止所有运行中的任务并等待其写回最终状态
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	jobs := make([]*job, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		j.stopped = true
+		jobs = append(jobs, j)
+	}
+	r.mu.Unlock()
+
+	for _, j := range jobs {
+		j.cancel()
+	}
+
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// IsRunning 判断任务是否正在执行
+func (r *Runner) IsRunning(taskID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.jobs[taskID]
+	return ok
+}
+
+// IsSiteRunning 判断站点是否有正在执行的任务
+func (r *Runner) IsSiteRunning(siteID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, j := range r.jobs {
+		if j.task.SiteID == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// run 执行任务并在结束后写回最终状态
+func (r *Runner) run(j *job, store storage.Storage) {
+	defer close(j.done)
+	defer j.cancel()
+	defer j.logger.Close()
+	defer store.Close()
+
+	taskID := j.task.ID
+
+	// 定期推送进度并写回统计信息
+	stopFlush := make(chan struct{})
+	go func() {
+		progressTicker := time.NewTicker(progressInterval)
+		defer progressTicker.Stop()
+		flushTicker := time.NewTicker(statsFlushInterval)
+		defer flushTicker.Stop()
+
+		var lastProcessed int
+		lastTime := time.Now()
+		for {
+			select {
+			case now := <-progressTicker.C:
+				stats := j.spider.Stats()
+				rate := float64(stats.ProcessedURLs-lastProcessed) / now.Sub(lastTime).Seconds()
+				lastProcessed, lastTime = stats.ProcessedURLs, now
+				r.events.Publish(Event{Type: EventProgress, TaskID: taskID, SiteID: j.task.SiteID, Status: constants.SpiderStatusRunning, Stats: &stats, Rate: rate})
+			case <-flushTicker.C:
+				r.flushStats(taskID, j.spider.Stats())
+			case <-stopFlush:
+				return
+			}
+		}
+	}()
+
+	err := j.spider.StartWithTask(j.ctx, j.task)
+	close(stopFlush)
+
+	r.mu.Lock()
+	stopped := j.stopped
+	r.mu.Unlock()
+
+	stats := j.spider.Stats()
+	// 全部URL都抓取失败时视为任务失败
+	if err == nil && stats.SuccessURLs == 0 && stats.FailedURLs > 0 {
+		err = fmt.Errorf("全部 %d 个URL抓取失败", stats.FailedURLs)
+	}
+
+	status := constants.SpiderStatusCompleted
+	var errMsg string
+	switch {
+	case stopped || errors.Is(err, context.Canceled):
+		status = constants.SpiderStatusStopped
+		err = nil
+	case err != nil:
+		status = constants.SpiderStatusFailed
+		errMsg = err.Error()
+	}
+
+	if dbErr := r.repos.Tasks.Finish(taskID, status, errMsg, taskStats(stats), time.Now()); dbErr != nil {
+		r.logger.Error("写回任务结果失败", "task_id", taskID, "error", dbErr)
+	}
+	if dbErr := r.repos.Sites.SetRunning(j.task.SiteID, false, time.Now()); dbErr != nil {
+		r.logger.Error("更新站点状态失败", "site_id", j.task.SiteID, "error", dbErr)
+	}
+
+	ev := Event{Type: EventStatus, TaskID: taskID, SiteID: j.task.SiteID, Status: status, Stats: &stats}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	r.events.Publish(ev)
+
+	// 写回结果并发布结束事件后才移除任务，IsRunning 返回 false 时，此前的订阅者已收到结束事件
+	r.mu.Lock()
+	delete(r.jobs, taskID)
+	r.mu.Unlock()
+
+	if err != nil {
+		j.logger.Error("任务执行失败", "error", err)
+	} else {
+		j.logger.Info("任务执行结束", "status", status, "items", stats.ItemsCount)
+	}
+}
+
+// flushStats 将运行中的统计写回任务
+func (r *Runner) flushStats(taskID int, stats crawler.Stats) {
+	if err := r.repos.Tasks.UpdateStats(taskID, taskStats(stats)); err != nil {
+		r.logger.Error("更新任务统计失败", "task_id", taskID, "error", err)
+	}
+}
+
+// taskStats 将爬取统计转换为任务统计
+func taskStats(stats crawler.Stats) repository.TaskStats {
+	return repository.TaskStats{
+		TotalURLs:     stats.TotalURLs,
+		ProcessedURLs: stats.ProcessedURLs,
+		SuccessURLs:   stats.SuccessURLs,
+		FailedURLs:    stats.FailedURLs,
+		SkippedURLs:   stats.SkippedURLs,
+		ItemsCount:    stats.ItemsCount,
+	}
+}
+
+// loadTask 读取任务及其站点配置，组装为爬虫任务
+func (r *Runner) loadTask(taskID int) (*models.CrawlTask, error) {
+	stored, err := r.repos.Tasks.Get(taskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+
+	site, err := r.repos.Sites.Get(stored.SiteID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSiteDisabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询站点失败: %w", err)
+	}
+	if !site.Enabled {
+		return nil, ErrSiteDisabled
+	}
+
+	task := &models.CrawlTask{
+		ID:        taskID,
+		SiteID:    site.ID,
+		Name:      site.Name,
+		BaseURL:   site.BaseURL,
+		StartURLs: site.StartURLs,
+		Selectors: site.Selectors,
+	}
+
+	// 站点规则作为默认值，任务配置中的同名字段覆盖站点规则
+	if rules, err := json.Marshal(site.Rules); err == nil {
+		json.Unmarshal(rules, &task.Rules)
+	}
+	if len(stored.Config) > 0 {
+		if config, err := json.Marshal(stored.Config); err == nil {
+			json.Unmarshal(config, &task.Rules)
+		}
+	}
+
+	return task, nil
+}
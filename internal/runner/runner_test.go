@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/pkg/constants"
+)
+
+// testLogger 将日志写入测试输出，只在测试失败或 -v 时显示
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Info(msg string, kv ...interface{})  { l.t.Logf("[INFO] %s %v", msg, kv) }
+func (l testLogger) Error(msg string, kv ...interface{}) { l.t.Logf("[ERROR] %s %v", msg, kv) }
+func (l testLogger) Warn(msg string, kv ...interface{})  { l.t.Logf("[WARN] %s %v", msg, kv) }
+func (l testLogger) Debug(msg string, kv ...interface{}) { l.t.Logf("[DEBUG] %s %v", msg, kv) }
+
+// slowFinish 写回任务结果前暂停，放大任务结束与发布结束事件之间的间隔
+type slowFinish struct {
+	repository.TaskRepository
+}
+
+func (s slowFinish) Finish(id int, status, errMsg string, stats repository.TaskStats, at time.Time) error {
+	time.Sleep(20 * time.Millisecond)
+	return s.TaskRepository.Finish(id, status, errMsg, stats, at)
+}
+
+// newTestRunner 创建使用内存存取接口的执行器，数据写入临时目录的 JSONL 文件。
+// 站点首页在 delay 后返回
+func newTestRunner(t *testing.T, delay time.Duration) (*Runner, *repository.Repositories, *repository.Site) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>首页</title></head><body>内容</body></html>")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{}
+	cfg.Spider = config.SpiderConfig{Concurrent: 1, Timeout: 5, UserAgent: "test-agent"}
+	cfg.Storage = config.StorageConfig{Type: constants.StorageTypeJSON, OutputDir: t.TempDir()}
+	cfg.Logging.Level = "info"
+
+	repos := repository.NewMemoryRepositories()
+	site := &repository.Site{Name: "站点", BaseURL: srv.URL, StartURLs: []string{srv.URL + "/"}, Enabled: true}
+	if err := repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+	return NewRunner(repos, cfg, testLogger{t}), repos, site
+}
+
+// waitStopped 等待任务结束
+func waitStopped(t *testing.T, r *Runner, taskID int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for r.IsRunning(taskID) {
+		if time.Now().After(deadline) {
+			t.Fatal("任务未在期限内结束")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunnerPublishesFinalStatusBeforeRemovingJob(t *testing.T) {
+	r, repos, site := newTestRunner(t, 0)
+	repos.Tasks = slowFinish{repos.Tasks}
+
+	for i := 0; i < 5; i++ {
+		events, unsubscribe := r.Events().Subscribe(0)
+		taskID, err := r.RunSite(site.ID, site.Name)
+		if err != nil {
+			t.Fatalf("启动任务失败: %v", err)
+		}
+		waitStopped(t, r, taskID)
+
+		// 任务移除时结束事件已经发布，任务结果已经写回
+		var final *Event
+	drain:
+		for {
+			select {
+			case ev := <-events:
+				if ev.TaskID == taskID && ev.Status != constants.SpiderStatusRunning {
+					final = &ev
+				}
+			default:
+				break drain
+			}
+		}
+		unsubscribe()
+
+		if final == nil || final.Status != constants.SpiderStatusCompleted || final.Stats.ItemsCount != 1 {
+			t.Fatalf("第 %d 次运行未收到结束事件: %+v", i+1, final)
+		}
+		task, err := repos.Tasks.Get(taskID)
+		if err != nil || task.Status != constants.SpiderStatusCompleted || task.EndTime == nil || task.SuccessURLs != 1 {
+			t.Fatalf("任务结果未写回: %+v, %v", task, err)
+		}
+	}
+}
+
+func TestRunnerStop(t *testing.T) {
+	r, repos, site := newTestRunner(t, 5*time.Second)
+
+	taskID, err := r.RunSite(site.ID, site.Name)
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	if err := r.Start(taskID); err != ErrTaskRunning {
+		t.Fatalf("重复启动应返回 ErrTaskRunning，实际 %v", err)
+	}
+	if !r.IsSiteRunning(site.ID) {
+		t.Fatal("站点应处于运行中")
+	}
+
+	if err := r.Stop(taskID); err != nil {
+		t.Fatalf("停止任务失败: %v", err)
+	}
+	waitStopped(t, r, taskID)
+
+	task, _ := repos.Tasks.Get(taskID)
+	if task.Status != constants.SpiderStatusStopped {
+		t.Fatalf("任务状态为 %s，期望 stopped", task.Status)
+	}
+	if got, _ := repos.Sites.Get(site.ID); got.Status == constants.SpiderStatusRunning {
+		t.Fatal("任务停止后站点仍为运行中")
+	}
+	if err := r.Stop(taskID); err != ErrTaskNotRunning {
+		t.Fatalf("停止已结束的任务应返回 ErrTaskNotRunning，实际 %v", err)
+	}
+}
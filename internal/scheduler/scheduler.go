@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/internal/runner"
+	"example.com/m/v2/internal/utils"
+)
+
+// checkInterval 检查到期站点的间隔
+const checkInterval = 30 * time.Second
+
+// minInterval 调度间隔的下限
+const minInterval = time.Minute
+
+// errIntervalTooShort 调度间隔小于下限
+var errIntervalTooShort = errors.New("调度间隔不能小于1分钟")
+
+// ParseSchedule 解析调度规则，支持标准 cron 表达式（如 "0 * * * *"）、
+// 描述符（如 "@hourly"、"@every 30m"）以及时间间隔（如 "1h"）
+func ParseSchedule(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("调度规则不能为空")
+	}
+
+	if interval, err := time.ParseDuration(expr); err == nil {
+		if interval < minInterval {
+			return nil, errIntervalTooShort
+		}
+		return cron.Every(interval), nil
+	}
+
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的调度规则 %q: %w", expr, err)
+	}
+	// "@every" 描述符同样受间隔下限约束，标准 cron 表达式的精度为1分钟
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok && every.Delay < minInterval {
+		return nil, errIntervalTooShort
+	}
+	return schedule, nil
+}
+
+// Scheduler 站点调度器，按站点配置的调度规则定时创建并启动任务
+type Scheduler struct {
+	sites  repository.SiteRepository
+	runner *runner.Runner
+	logger utils.Logger
+}
+
+// NewScheduler 创建站点调度器
+func NewScheduler(sites repository.SiteRepository, taskRunner *runner.Runner, logger utils.Logger) *Scheduler {
+	return &Scheduler{
+		sites:  sites,
+		runner: taskRunner,
+		logger: logger,
+	}
+}
+
+// Run 定期检查到期站点，直到 ctx 取消
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("站点调度器已启动", "check_interval", checkInterval)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.logger.Info("站点调度器已停止")
+			return
+		}
+	}
+}
+
+// tick 启动所有到期站点的任务，并计算下次运行时间
+func (s *Scheduler) tick(now time.Time) {
+	sites, err := s.sites.ListScheduled()
+	if err != nil {
+		s.logger.Error("查询调度站点失败", "error", err)
+		return
+	}
+
+	for _, site := range sites {
+		schedule, err := ParseSchedule(site.Schedule)
+		if err != nil {
+			s.logger.Error("站点调度规则无效", "site", site.SiteName, "schedule", site.Schedule, "error", err)
+			continue
+		}
+
+		// 首次启用调度时只计算下次运行时间，不立即运行
+		due := site.NextRunAt != nil && !site.NextRunAt.After(now)
+		if due {
+			s.runSite(site)
+		}
+
+		if site.NextRunAt == nil || due {
+			if err := s.sites.SetNextRun(site.SiteID, schedule.Next(now)); err != nil {
+				s.logger.Error("更新下次运行时间失败", "site", site.SiteName, "error", err)
+			}
+		}
+	}
+}
+
+// runSite 启动站点任务，上一次运行尚未结束时跳过本次运行
+func (s *Scheduler) runSite(site repository.Schedule) {
+	if s.runner.IsSiteRunning(site.SiteID) {
+		s.logger.Warn("站点上次任务仍在运行，跳过本次调度", "site", site.SiteName)
+		return
+	}
+
+	taskID, err := s.runner.RunSite(site.SiteID, site.SiteName)
+	if err != nil {
+		s.logger.Error("调度启动任务失败", "site", site.SiteName, "task_id", taskID, "error", err)
+		return
+	}
+
+	s.logger.Info("调度启动任务", "site", site.SiteName, "task_id", taskID)
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2026, 1, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time // 为零值时期望解析失败
+	}{
+		{"1h", from.Add(time.Hour)},
+		{" 90m ", from.Add(90 * time.Minute)},
+		{"1m", from.Add(time.Minute)},
+		{"@every 30m", from.Add(30 * time.Minute)},
+		{"@every 1m", from.Add(time.Minute)},
+		{"@hourly", time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"", time.Time{}},
+		{"30s", time.Time{}},
+		{"@every 10s", time.Time{}},
+		{"@every 59s", time.Time{}},
+		{"* * *", time.Time{}},
+		{"hourly", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.expr)
+		if tt.next.IsZero() {
+			if err == nil {
+				t.Errorf("ParseSchedule(%q) 应返回错误", tt.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) 失败: %v", tt.expr, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(tt.next) {
+			t.Errorf("ParseSchedule(%q).Next = %v，期望 %v", tt.expr, next, tt.next)
+		}
+	}
+}
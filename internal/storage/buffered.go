@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"example.com/m/v2/pkg/models"
+)
+
+// ErrStorageClosed 存储已停止写入
+var ErrStorageClosed = errors.New("存储已停止写入")
+
+// BufferOptions 异步写入参数
+type BufferOptions struct {
+	QueueSize     int           // 队列容量，队列满时 Save 阻塞等待
+	Workers       int           // 写入协程数
+	BatchSize     int           // 每批写入的最大条数
+	FlushInterval time.Duration // 未满一批时的最长等待时间
+
+	// OnSaved 一批数据写入后回调，saved 为实际写入的条数，不含去重跳过的数据。
+	// 组合存储时以第一个存储为准
+	OnSaved func(ic ItemContext, saved int)
+	// OnError 数据写入失败时回调
+	OnError func(ic ItemContext, item *models.Item, err error)
+}
+
+// bufferedItem 队列中待写入的数据项
+type bufferedItem struct {
+	ic   ItemContext
+	item *models.Item
+}
+
+// BufferedStorage 异步批量写入的存储。Save 只将数据放入有界队列，由写入协程攒批后调用
+// 底层存储的 SaveBatch；队列满时 Save 阻塞，从而让爬虫放慢速度
+type BufferedStorage struct {
+	store Storage
+	opts  BufferOptions
+	queue chan bufferedItem
+	wg    sync.WaitGroup
+
+	mu       sync.RWMutex
+	closed   bool
+	stopOnce sync.Once
+}
+
+// NewBufferedStorage 创建异步写入存储并启动写入协程
+func NewBufferedStorage(store Storage, opts BufferOptions) *BufferedStorage {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	bs := &BufferedStorage{
+		store: store,
+		opts:  opts,
+		queue: make(chan bufferedItem, opts.QueueSize),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		bs.wg.Add(1)
+		go bs.worker()
+	}
+	return bs
+}
+
+// Save 将数据项放入写入队列，停止后返回 ErrStorageClosed
+func (bs *BufferedStorage) Save(ic ItemContext, item *models.Item) error {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+
+	if bs.closed {
+		return ErrStorageClosed
+	}
+	bs.queue <- bufferedItem{ic: ic, item: item}
+	return nil
+}
+
+// SaveBatch 将多个数据项放入写入队列，返回放入队列的条数
+func (bs *BufferedStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	for i, item := range items {
+		if err := bs.Save(ic, item); err != nil {
+			return i, err
+		}
+	}
+	return len(items), nil
+}
+
+// Stop 停止接收新数据，并等待队列中的数据全部写入。不关闭底层存储
+func (bs *BufferedStorage) Stop() {
+	bs.stopOnce.Do(func() {
+		bs.mu.Lock()
+		bs.closed = true
+		close(bs.queue)
+		bs.mu.Unlock()
+	})
+	bs.wg.Wait()
+}
+
+// Close 写入剩余数据后关闭底层存储
+func (bs *BufferedStorage) Close() error {
+	bs.Stop()
+	return bs.store.Close()
+}
+
+// worker 从队列读取数据，攒满一批或到达刷新间隔时写入
+func (bs *BufferedStorage) worker() {
+	defer bs.wg.Done()
+
+	ticker := time.NewTicker(bs.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batches := map[ItemContext][]*models.Item{}
+	pending := 0
+	flush := func() {
+		for ic, items := range batches {
+			bs.write(ic, items)
+		}
+		clear(batches)
+		pending = 0
+	}
+
+	for {
+		select {
+		case entry, ok := <-bs.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batches[entry.ic] = append(batches[entry.ic], entry.item)
+			pending++
+			if pending >= bs.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write 批量写入数据。组合存储时分别写入各个存储，一个存储失败后的重试不会在其他存储中重复写入
+func (bs *BufferedStorage) write(ic ItemContext, items []*models.Item) {
+	stores := []Storage{bs.store}
+	if ms, ok := bs.store.(*MultiStorage); ok {
+		stores = ms.stores
+	}
+
+	saved := 0
+	for i, store := range stores {
+		n := bs.writeStore(store, ic, items)
+		if i == 0 {
+			saved = n
+		}
+	}
+
+	if bs.opts.OnSaved != nil && saved > 0 {
+		bs.opts.OnSaved(ic, saved)
+	}
+}
+
+// writeStore 将一批数据写入单个存储，整批失败时逐条重试尚未写入的数据，避免一条坏数据导致整批丢失。
+// 返回实际写入的条数
+func (bs *BufferedStorage) writeStore(store Storage, ic ItemContext, items []*models.Item) int {
+	saved, err := store.SaveBatch(ic, items)
+	if err == nil {
+		return saved
+	}
+
+	for _, item := range items[saved:] {
+		n, err := store.SaveBatch(ic, []*models.Item{item})
+		if err != nil {
+			if bs.opts.OnError != nil {
+				bs.opts.OnError(ic, item, err)
+			}
+			continue
+		}
+		saved += n
+	}
+	return saved
+}
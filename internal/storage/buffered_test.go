@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/m/v2/pkg/models"
+)
+
+// rejectStorage 记录每批写入的数据，拒绝 URL 包含 bad 的数据项，之前的数据照常写入
+type rejectStorage struct {
+	mu      sync.Mutex
+	batches [][]string
+	saved   []string
+}
+
+var errBadItem = errors.New("bad item")
+
+func (s *rejectStorage) Save(ic ItemContext, item *models.Item) error {
+	_, err := s.SaveBatch(ic, []*models.Item{item})
+	return err
+}
+
+func (s *rejectStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var batch []string
+	for _, item := range items {
+		batch = append(batch, item.URL)
+	}
+	s.batches = append(s.batches, batch)
+	for i, item := range items {
+		if strings.Contains(item.URL, "bad") {
+			return i, errBadItem
+		}
+		s.saved = append(s.saved, item.URL)
+	}
+	return len(items), nil
+}
+
+func (s *rejectStorage) Close() error {
+	return nil
+}
+
+func urlItems(urls ...string) []*models.Item {
+	items := make([]*models.Item, len(urls))
+	for i, url := range urls {
+		items[i] = &models.Item{URL: url}
+	}
+	return items
+}
+
+func TestBufferedStorageBatches(t *testing.T) {
+	store := &rejectStorage{}
+	var mu sync.Mutex
+	saved := 0
+	bs := NewBufferedStorage(store, BufferOptions{
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		OnSaved: func(ic ItemContext, n int) {
+			mu.Lock()
+			saved += n
+			mu.Unlock()
+		},
+	})
+
+	if n, err := bs.SaveBatch(ItemContext{SiteID: 1}, urlItems("a", "b", "c", "d", "e", "f", "g")); err != nil || n != 7 {
+		t.Fatalf("放入队列: n=%d err=%v", n, err)
+	}
+	bs.Stop()
+
+	if len(store.batches) != 3 || len(store.batches[0]) != 3 || len(store.batches[2]) != 1 {
+		t.Fatalf("批次 = %v，期望 3+3+1", store.batches)
+	}
+	if saved != 7 {
+		t.Fatalf("OnSaved 共 %d 条，期望 7", saved)
+	}
+	if err := bs.Save(ItemContext{SiteID: 1}, &models.Item{URL: "h"}); !errors.Is(err, ErrStorageClosed) {
+		t.Fatalf("停止后保存返回 %v，期望 ErrStorageClosed", err)
+	}
+}
+
+func TestBufferedStorageRetries(t *testing.T) {
+	tests := []struct {
+		name  string
+		multi bool
+	}{
+		{"single", false},
+		{"multi", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 组合存储时第一个存储不拒绝数据，第二个存储的重试不能让它重复写入
+			first, store := &discardStore{}, &rejectStorage{}
+			var target Storage = store
+			if tt.multi {
+				target = NewMultiStorage(first, store)
+			}
+
+			var failed []string
+			saved := 0
+			bs := NewBufferedStorage(target, BufferOptions{
+				BatchSize:     5,
+				FlushInterval: time.Hour,
+				OnSaved:       func(ic ItemContext, n int) { saved += n },
+				OnError:       func(ic ItemContext, item *models.Item, err error) { failed = append(failed, item.URL) },
+			})
+			bs.SaveBatch(ItemContext{SiteID: 1}, urlItems("a", "b", "bad", "c", "d"))
+			bs.Stop()
+
+			if got := strings.Join(store.saved, ","); got != "a,b,c,d" {
+				t.Fatalf("写入 %s，期望 a,b,c,d", got)
+			}
+			// 整批失败后只重试未写入的数据
+			if len(store.batches) != 4 || strings.Join(store.batches[1], ",") != "bad" {
+				t.Fatalf("批次 = %v", store.batches)
+			}
+			if len(failed) != 1 || failed[0] != "bad" {
+				t.Fatalf("失败的数据 = %v", failed)
+			}
+			if tt.multi {
+				if first.count != 5 || saved != 5 {
+					t.Fatalf("第一个存储写入 %d 条，OnSaved %d 条，期望 5 条", first.count, saved)
+				}
+			} else if saved != 4 {
+				t.Fatalf("OnSaved %d 条，期望 4 条", saved)
+			}
+		})
+	}
+}
+
+// discardStore 只记录写入条数的存储
+type discardStore struct {
+	count int
+}
+
+func (s *discardStore) Save(ic ItemContext, item *models.Item) error {
+	s.count++
+	return nil
+}
+
+func (s *discardStore) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	s.count += len(items)
+	return len(items), nil
+}
+
+func (s *discardStore) Close() error {
+	return nil
+}
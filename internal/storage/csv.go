@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/export"
+	"example.com/m/v2/pkg/models"
+)
+
+// NewCSVStorage 创建 CSV 文件存储。文件以 UTF-8 BOM 开头，便于 Excel 正确识别中文
+func NewCSVStorage(cfg config.StorageConfig) (*FileStorage, error) {
+	return newFileStorage(cfg, "csv", 0, openCSV)
+}
+
+// csvWriter CSV 文件写入器
+type csvWriter struct {
+	file   *os.File
+	w      *csv.Writer
+	record []string
+}
+
+func openCSV(path string) (fileWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.WriteString("\xEF\xBB\xBF"); err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := csv.NewWriter(file)
+	if err := w.Write(export.Header(fileColumns)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvWriter{file: file, w: w, record: make([]string, len(fileColumns))}, nil
+}
+
+func (w *csvWriter) write(ic ItemContext, item *models.Item) error {
+	for i, value := range recordValues(ic, item) {
+		w.record[i] = fmt.Sprint(value)
+	}
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) close() error {
+	if err := w.flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
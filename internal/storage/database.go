@@ -2,264 +2,231 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
-
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/mattn/go-sqlite3"
+	"sync"
+	"time"
 
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/pkg/constants"
 	"example.com/m/v2/pkg/models"
 )
 
-// DatabaseStorage 数据库存储实现
+// execer 数据库连接和事务共有的执行方法
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// DatabaseStorage 数据库存储实现，写入 database 包定义的 crawl_data 表。
+// 每条数据记录内容指纹，重复抓取同一URL时比较指纹得出变更状态（new、updated、unchanged）；
+// 启用去重时内容未变化的数据只更新检查时间，与已有数据重复的新URL不写入
 type DatabaseStorage struct {
-	config config.StorageConfig
-	db     *sql.DB
-	driver string
+	config  config.StorageConfig
+	filters config.FiltersConfig
+	db      *database.DB
+
+	// simhashes 各站点已保存数据的 SimHash，按 content 近似去重时按需加载
+	mu        sync.Mutex
+	simhashes map[int][]uint64
 }
 
 // NewDatabaseStorage 创建数据库存储实例
-func NewDatabaseStorage(cfg config.StorageConfig) (*DatabaseStorage, error) {
-	storage := &DatabaseStorage{
-		config: cfg,
-		driver: cfg.Database.Driver,
+func NewDatabaseStorage(cfg config.StorageConfig, filters config.FiltersConfig) (*DatabaseStorage, error) {
+	switch filters.DedupField {
+	case "", constants.DedupFieldURL, constants.DedupFieldTitle, constants.DedupFieldContent:
+	default:
+		return nil, fmt.Errorf("不支持的去重字段: %s", filters.DedupField)
 	}
 
-	// 连接数据库
-	if err := storage.connect(); err != nil {
+	db, err := database.NewConnection(cfg.Database)
+	if err != nil {
 		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
 
-	// 创建表
-	if err := storage.createTable(); err != nil {
-		return nil, fmt.Errorf("创建表失败: %w", err)
-	}
-
-	return storage, nil
+	return &DatabaseStorage{
+		config:    cfg,
+		filters:   filters,
+		db:        db,
+		simhashes: make(map[int][]uint64),
+	}, nil
 }
 
-// connect 连接数据库
-func (ds *DatabaseStorage) connect() error {
-	var dsn string
-
-	switch ds.driver {
-	case "sqlite3":
-		dsn = "./data/crawler.db"
-	case "mysql":
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			ds.config.Database.Username,
-			ds.config.Database.Password,
-			ds.config.Database.Host,
-			ds.config.Database.Port,
-			ds.config.Database.Database,
-		)
-	default:
-		return fmt.Errorf("不支持的数据库驱动: %s", ds.driver)
-	}
-
-	db, err := sql.Open(ds.driver, dsn)
+// Save 保存单个数据项
+func (ds *DatabaseStorage) Save(ic ItemContext, item *models.Item) error {
+	simhash, _, added, err := ds.save(ds.db, ic, item)
 	if err != nil {
 		return err
 	}
-
-	// 测试连接
-	if err := db.Ping(); err != nil {
-		return err
+	if added {
+		ds.addSimHash(ic.SiteID, simhash)
 	}
-
-	ds.db = db
 	return nil
 }
 
-// createTable 创建表
-func (ds *DatabaseStorage) createTable() error {
-	var createSQL string
-
-	switch ds.driver {
-	case "sqlite3":
-		createSQL = `
-		CREATE TABLE IF NOT EXISTS crawl_data (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			url TEXT NOT NULL,
-			title TEXT,
-			content TEXT,
-			description TEXT,
-			author TEXT,
-			source TEXT,
-			publish_date DATETIME,
-			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-			keywords TEXT,
-			tags TEXT,
-			links TEXT,
-			images TEXT,
-			UNIQUE(url)
-		)`
-	case "mysql":
-		createSQL = `
-		CREATE TABLE IF NOT EXISTS crawl_data (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			url VARCHAR(1000) NOT NULL,
-			title TEXT,
-			content LONGTEXT,
-			description TEXT,
-			author VARCHAR(255),
-			source VARCHAR(255),
-			publish_date DATETIME,
-			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-			keywords TEXT,
-			tags TEXT,
-			links TEXT,
-			images TEXT,
-			UNIQUE KEY unique_url (url)
-		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
-	default:
-		return fmt.Errorf("不支持的数据库驱动: %s", ds.driver)
+// SaveBatch 在一个事务中批量保存数据项，返回实际写入的条数，不含去重跳过和内容未变化的数据。
+// 出错时整批回滚，写入条数为 0
+func (ds *DatabaseStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	// 开始事务
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开始事务失败: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err := ds.db.Exec(createSQL)
-	return err
-}
-
-// Save 保存单个数据项
-func (ds *DatabaseStorage) Save(item *models.Item) error {
-	insertSQL := `
-	INSERT OR REPLACE INTO crawl_data 
-	(url, title, content, description, author, source, publish_date, timestamp, keywords, tags, links, images)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	if ds.driver == "mysql" {
-		insertSQL = `
-		INSERT INTO crawl_data 
-		(url, title, content, description, author, source, publish_date, timestamp, keywords, tags, links, images)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-		title = VALUES(title),
-		content = VALUES(content),
-		description = VALUES(description),
-		author = VALUES(author),
-		source = VALUES(source),
-		publish_date = VALUES(publish_date),
-		timestamp = VALUES(timestamp),
-		keywords = VALUES(keywords),
-		tags = VALUES(tags),
-		links = VALUES(links),
-		images = VALUES(images)`
+	// 事务提交后才记录新数据的 SimHash，回滚时不影响后续去重
+	var added []uint64
+	saved := 0
+	for _, item := range items {
+		simhash, written, ok, err := ds.save(tx, ic, item)
+		if err != nil {
+			return 0, fmt.Errorf("执行插入失败: %w", err)
+		}
+		if written {
+			saved++
+		}
+		if ok {
+			added = append(added, simhash)
+		}
 	}
 
-	// 处理时间字段
-	var publishDate interface{}
-	if item.PublishDate.IsZero() {
-		publishDate = nil
-	} else {
-		publishDate = item.PublishDate
+	// 提交事务
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
 	}
 
-	// 将数组转换为字符串
-	keywords := strings.Join(item.Keywords, ";")
-	tags := strings.Join(item.Tags, ";")
-	links := strings.Join(item.Links, ";")
-	images := strings.Join(item.Images, ";")
-
-	_, err := ds.db.Exec(insertSQL,
-		item.URL,
-		item.Title,
-		item.Content,
-		item.Description,
-		item.Author,
-		item.Source,
-		publishDate,
-		item.Timestamp,
-		keywords,
-		tags,
-		links,
-		images,
-	)
-
-	return err
+	for _, simhash := range added {
+		ds.addSimHash(ic.SiteID, simhash)
+	}
+	return saved, nil
 }
 
-// SaveBatch 批量保存数据项
-func (ds *DatabaseStorage) SaveBatch(items []*models.Item) error {
-	// 开始事务
-	tx, err := ds.db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
+// save 按变更状态写入数据项，返回内容的 SimHash、是否写入了数据行，以及是否新增了需要参与近似去重的记录
+func (ds *DatabaseStorage) save(q execer, ic ItemContext, item *models.Item) (uint64, bool, bool, error) {
+	hash := item.ContentHash()
+	simhash := item.SimHash()
+	checkedAt := item.Timestamp
+	if checkedAt.IsZero() {
+		checkedAt = time.Now()
 	}
-	defer tx.Rollback()
 
-	// 准备插入语句
-	insertSQL := `
-	INSERT OR REPLACE INTO crawl_data 
-	(url, title, content, description, author, source, publish_date, timestamp, keywords, tags, links, images)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	if ds.driver == "mysql" {
-		insertSQL = `
-		INSERT INTO crawl_data 
-		(url, title, content, description, author, source, publish_date, timestamp, keywords, tags, links, images)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-		title = VALUES(title),
-		content = VALUES(content),
-		description = VALUES(description),
-		author = VALUES(author),
-		source = VALUES(source),
-		publish_date = VALUES(publish_date),
-		timestamp = VALUES(timestamp),
-		keywords = VALUES(keywords),
-		tags = VALUES(tags),
-		links = VALUES(links),
-		images = VALUES(images)`
+	var id int
+	var oldHash sql.NullString
+	err := q.QueryRow("SELECT id, content_hash FROM crawl_data WHERE url = ? AND site_id = ?", item.URL, ic.SiteID).
+		Scan(&id, &oldHash)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, false, false, err
 	}
+	exists := err == nil
 
-	stmt, err := tx.Prepare(insertSQL)
-	if err != nil {
-		return fmt.Errorf("准备语句失败: %w", err)
+	change := constants.ChangeStatusNew
+	if exists {
+		change = constants.ChangeStatusUpdated
+		if oldHash.String == hash {
+			change = constants.ChangeStatusUnchanged
+		}
 	}
-	defer stmt.Close()
 
-	// 批量插入
-	for _, item := range items {
-		// 处理时间字段
-		var publishDate interface{}
-		if item.PublishDate.IsZero() {
-			publishDate = nil
-		} else {
-			publishDate = item.PublishDate
+	if ds.filters.EnableDeduplication {
+		// 内容未变化，只记录本次检查
+		if change == constants.ChangeStatusUnchanged {
+			_, err := q.Exec("UPDATE crawl_data SET change_status = ?, checked_at = ? WHERE id = ?", change, checkedAt, id)
+			return simhash, false, false, err
 		}
+		if !exists {
+			duplicate, err := ds.isDuplicate(q, ic.SiteID, item, hash, simhash)
+			if err != nil || duplicate {
+				return simhash, false, false, err
+			}
+		}
+	}
 
-		// 将数组转换为字符串
-		keywords := strings.Join(item.Keywords, ";")
-		tags := strings.Join(item.Tags, ";")
-		links := strings.Join(item.Links, ";")
-		images := strings.Join(item.Images, ";")
-
-		_, err := stmt.Exec(
-			item.URL,
-			item.Title,
-			item.Content,
-			item.Description,
-			item.Author,
-			item.Source,
-			publishDate,
-			item.Timestamp,
-			keywords,
-			tags,
-			links,
-			images,
-		)
+	row := itemRow(ic, item)
+	row.ContentHash = hash
+	row.ChangeStatus = change
+	row.CheckedAt = &checkedAt
+	if err := repository.UpsertItem(q, ds.db.Dialect, row, simhash); err != nil {
+		return 0, false, false, err
+	}
+	return simhash, true, !exists && ds.nearDedup(), nil
+}
+
+// nearDedup 是否按 SimHash 去除近似重复的内容
+func (ds *DatabaseStorage) nearDedup() bool {
+	return ds.filters.EnableDeduplication && ds.filters.DedupField == constants.DedupFieldContent && ds.filters.SimHashDistance > 0
+}
+
+// isDuplicate 判断新URL的数据是否与站点中已有数据重复，去重字段为 url 时URL不同即不重复
+func (ds *DatabaseStorage) isDuplicate(q execer, siteID int, item *models.Item, hash string, simhash uint64) (bool, error) {
+	var count int
+	switch ds.filters.DedupField {
+	case constants.DedupFieldTitle:
+		if strings.TrimSpace(item.Title) == "" {
+			return false, nil
+		}
+		err := q.QueryRow("SELECT COUNT(*) FROM crawl_data WHERE site_id = ? AND title = ?", siteID, item.Title).Scan(&count)
+		return count > 0, err
+
+	case constants.DedupFieldContent:
+		// SimHash 为 0 表示没有可比较的文本
+		if simhash == 0 {
+			return false, nil
+		}
+		err := q.QueryRow("SELECT COUNT(*) FROM crawl_data WHERE site_id = ? AND content_hash = ?", siteID, hash).Scan(&count)
+		if err != nil || count > 0 || !ds.nearDedup() {
+			return count > 0, err
+		}
+		return ds.nearDuplicate(q, siteID, simhash)
+	}
+	return false, nil
+}
+
+// nearDuplicate 判断站点中是否有 SimHash 汉明距离不超过阈值的数据
+func (ds *DatabaseStorage) nearDuplicate(q execer, siteID int, simhash uint64) (bool, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	hashes, loaded := ds.simhashes[siteID]
+	if !loaded {
+		rows, err := q.Query("SELECT simhash FROM crawl_data WHERE site_id = ? AND simhash IS NOT NULL", siteID)
 		if err != nil {
-			return fmt.Errorf("执行插入失败: %w", err)
+			return false, err
+		}
+		defer rows.Close()
+		hashes = []uint64{}
+		for rows.Next() {
+			var value int64
+			if err := rows.Scan(&value); err != nil {
+				return false, err
+			}
+			hashes = append(hashes, uint64(value))
 		}
+		if err := rows.Err(); err != nil {
+			return false, err
+		}
+		ds.simhashes[siteID] = hashes
 	}
 
-	// 提交事务
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("提交事务失败: %w", err)
+	for _, h := range hashes {
+		if models.HammingDistance(h, simhash) <= ds.filters.SimHashDistance {
+			return true, nil
+		}
 	}
+	return false, nil
+}
 
-	return nil
+// addSimHash 记录新保存数据的 SimHash，站点的 SimHash 尚未加载时由 nearDuplicate 从数据库读取
+func (ds *DatabaseStorage) addSimHash(siteID int, simhash uint64) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if hashes, loaded := ds.simhashes[siteID]; loaded {
+		ds.simhashes[siteID] = append(hashes, simhash)
+	}
 }
 
 // Close 关闭数据库连接
@@ -284,7 +251,10 @@ func (ds *DatabaseStorage) Count() (int, error) {
 
 // GetLatest 获取最新数据
 func (ds *DatabaseStorage) GetLatest(limit int) ([]*models.Item, error) {
-	query := "SELECT url, title, content, description, author, source, publish_date, timestamp, keywords, tags, links, images FROM crawl_data ORDER BY timestamp DESC LIMIT ?"
+	query := `
+		SELECT url, title, content, description, author, source, language, publish_date, crawl_time,
+		       keywords, tags, links, images, videos, metadata, status
+		FROM crawl_data ORDER BY crawl_time DESC LIMIT ?`
 
 	rows, err := ds.db.Query(query, limit)
 	if err != nil {
@@ -295,48 +265,96 @@ func (ds *DatabaseStorage) GetLatest(limit int) ([]*models.Item, error) {
 	var items []*models.Item
 	for rows.Next() {
 		item := &models.Item{}
+		var title, content, description, author, source, language sql.NullString
+		var keywords, tags, links, images, videos, metadata sql.NullString
 		var publishDate sql.NullTime
-		var keywords, tags, links, images string
 
 		err := rows.Scan(
 			&item.URL,
-			&item.Title,
-			&item.Content,
-			&item.Description,
-			&item.Author,
-			&item.Source,
+			&title,
+			&content,
+			&description,
+			&author,
+			&source,
+			&language,
 			&publishDate,
 			&item.Timestamp,
 			&keywords,
 			&tags,
 			&links,
 			&images,
+			&videos,
+			&metadata,
+			&item.Status,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		item.Title = title.String
+		item.Content = content.String
+		item.Description = description.String
+		item.Author = author.String
+		item.Source = source.String
+		item.Language = language.String
+
 		// 处理时间字段
 		if publishDate.Valid {
 			item.PublishDate = publishDate.Time
 		}
 
-		// 将字符串转换为数组
-		if keywords != "" {
-			item.Keywords = strings.Split(keywords, ";")
-		}
-		if tags != "" {
-			item.Tags = strings.Split(tags, ";")
-		}
-		if links != "" {
-			item.Links = strings.Split(links, ";")
-		}
-		if images != "" {
-			item.Images = strings.Split(images, ";")
-		}
+		// 解析JSON字段
+		decodeJSON(keywords, &item.Keywords)
+		decodeJSON(tags, &item.Tags)
+		decodeJSON(links, &item.Links)
+		decodeJSON(images, &item.Images)
+		decodeJSON(videos, &item.Videos)
+		decodeJSON(metadata, &item.Metadata)
 
 		items = append(items, item)
 	}
 
-	return items, nil
+	return items, rows.Err()
+}
+
+// itemRow 将数据项转换为 crawl_data 的数据记录，指纹和变更状态由调用方设置
+func itemRow(ic ItemContext, item *models.Item) *repository.Item {
+	row := &repository.Item{
+		SiteID:       ic.SiteID,
+		URL:          item.URL,
+		Title:        item.Title,
+		Content:      item.Content,
+		Description:  item.Description,
+		Author:       item.Author,
+		Source:       item.Source,
+		Language:     item.Language,
+		Keywords:     item.Keywords,
+		Tags:         item.Tags,
+		Links:        item.Links,
+		Images:       item.Images,
+		Videos:       item.Videos,
+		Metadata:     item.Metadata,
+		Status:       item.Status,
+		CrawlTime:    item.Timestamp,
+		ViewCount:    item.ViewCount,
+		CommentCount: item.CommentCount,
+		LikeCount:    item.LikeCount,
+		ShareCount:   item.ShareCount,
+	}
+	if ic.TaskID > 0 {
+		taskID := ic.TaskID
+		row.TaskID = &taskID
+	}
+	if !item.PublishDate.IsZero() {
+		publishDate := item.PublishDate
+		row.PublishDate = &publishDate
+	}
+	return row
+}
+
+// decodeJSON 解析 JSON 字段，NULL 或空值时保持零值
+func decodeJSON(s sql.NullString, v interface{}) {
+	if s.Valid && s.String != "" && s.String != "null" {
+		json.Unmarshal([]byte(s.String), v)
+	}
 }
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/database"
+	"example.com/m/v2/internal/repository"
+	"example.com/m/v2/pkg/constants"
+	"example.com/m/v2/pkg/models"
+)
+
+// newTestDatabase 创建迁移到最新版本的 SQLite 数据库存储，返回存储、数据存取接口和一个站点ID
+func newTestDatabase(t *testing.T, filters config.FiltersConfig) (*DatabaseStorage, *repository.Repositories, int) {
+	t.Helper()
+
+	cfg := config.StorageConfig{
+		Type:     constants.StorageTypeDatabase,
+		Database: config.DBConfig{Driver: "sqlite3", SQLiteFile: filepath.Join(t.TempDir(), "test.db")},
+	}
+	db, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.Migrate(db); err != nil {
+		t.Fatalf("迁移数据库失败: %v", err)
+	}
+
+	repos := repository.NewSQLRepositories(db)
+	site := &repository.Site{Name: "test", BaseURL: "https://example.com", Enabled: true}
+	if err := repos.Sites.Create(site); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+
+	ds, err := NewDatabaseStorage(cfg, filters)
+	if err != nil {
+		t.Fatalf("创建数据库存储失败: %v", err)
+	}
+	t.Cleanup(func() { ds.Close() })
+	return ds, repos, site.ID
+}
+
+// TestDatabaseStorageSharesItemUpsert 爬虫写入和数据接口保存同一URL时使用同一写入，指纹和变更状态一致
+func TestDatabaseStorageSharesItemUpsert(t *testing.T) {
+	ds, repos, siteID := newTestDatabase(t, config.FiltersConfig{})
+
+	item := &models.Item{
+		URL:         "https://example.com/a",
+		Title:       "标题",
+		Content:     "正文内容",
+		Keywords:    []string{"go"},
+		PublishDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Timestamp:   time.Now(),
+	}
+	if err := ds.Save(ItemContext{SiteID: siteID}, item); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	items, total, err := repos.Items.List(repository.ItemFilter{SiteID: siteID, WithContent: true})
+	if err != nil || total != 1 {
+		t.Fatalf("查询数据: total=%d err=%v", total, err)
+	}
+	saved := items[0]
+	if saved.TaskID != nil || saved.Title != item.Title || saved.Content != item.Content ||
+		len(saved.Keywords) != 1 || saved.Tags == nil || saved.Metadata == nil || saved.PublishDate == nil {
+		t.Fatalf("保存的数据不一致: %+v", saved)
+	}
+	if saved.ContentHash != item.ContentHash() || saved.ChangeStatus != constants.ChangeStatusNew || saved.Status != "new" {
+		t.Fatalf("指纹或状态不一致: hash=%s change=%s status=%s", saved.ContentHash, saved.ChangeStatus, saved.Status)
+	}
+
+	// 数据接口按相同规则计算指纹，内容未变化
+	saved.Content = item.Content
+	created, err := repos.Items.Save(&saved)
+	if err != nil || created {
+		t.Fatalf("重新保存: created=%v err=%v", created, err)
+	}
+	if saved.ChangeStatus != constants.ChangeStatusUnchanged {
+		t.Fatalf("变更状态 = %s，期望 %s", saved.ChangeStatus, constants.ChangeStatusUnchanged)
+	}
+
+	// 爬虫再次抓取到变化的内容
+	item.Content = "新的正文"
+	if _, err := ds.SaveBatch(ItemContext{SiteID: siteID}, []*models.Item{item}); err != nil {
+		t.Fatalf("批量保存失败: %v", err)
+	}
+	updated, err := repos.Items.Get(saved.ID)
+	if err != nil {
+		t.Fatalf("查询数据失败: %v", err)
+	}
+	if updated.ChangeStatus != constants.ChangeStatusUpdated || updated.ContentHash != item.ContentHash() {
+		t.Fatalf("变更状态 = %s，期望 %s", updated.ChangeStatus, constants.ChangeStatusUpdated)
+	}
+}
+
+func TestDatabaseStorageDedup(t *testing.T) {
+	text := strings.Repeat("网络爬虫按站点配置抓取页面并提取标题和正文内容，", 6)
+	near := strings.Replace(text, "抓取", "下载", 1)
+
+	type step struct {
+		url, title, content string
+		written             bool
+	}
+	tests := []struct {
+		name    string
+		filters config.FiltersConfig
+		steps   []step
+		rows    int
+	}{
+		{"不去重", config.FiltersConfig{}, []step{
+			{"/a", "标题", text, true},
+			{"/a", "标题", text, true},
+			{"/b", "标题", text, true},
+		}, 2},
+		{"按URL", config.FiltersConfig{EnableDeduplication: true, DedupField: constants.DedupFieldURL}, []step{
+			{"/a", "标题", text, true},
+			{"/a", "标题", text, false}, // 内容未变化
+			{"/a", "标题", near, true},
+			{"/b", "标题", near, true},
+		}, 2},
+		{"按标题", config.FiltersConfig{EnableDeduplication: true, DedupField: constants.DedupFieldTitle}, []step{
+			{"/a", "标题", text, true},
+			{"/b", "标题", "其他内容", false},
+			{"/c", "", "其他内容", true}, // 空标题不参与去重
+			{"/d", "", "其他内容", true},
+		}, 3},
+		{"按内容", config.FiltersConfig{EnableDeduplication: true, DedupField: constants.DedupFieldContent}, []step{
+			{"/a", "标题", text, true},
+			{"/b", "标题", text, false},
+			{"/c", "标题", near, true},
+			{"/d", "", "", true}, // 没有文本时不参与去重
+			{"/e", "", "", true},
+		}, 4},
+		{"近似内容", config.FiltersConfig{EnableDeduplication: true, DedupField: constants.DedupFieldContent, SimHashDistance: 3}, []step{
+			{"/a", "标题", text, true},
+			{"/b", "标题", near, false},
+			{"/c", "另一篇", "The quick brown fox jumps over the lazy dog again and again.", true},
+			{"/d", "另一篇", "The quick brown fox jumps over the lazy dog again and again!", false},
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, repos, siteID := newTestDatabase(t, tt.filters)
+			for i, s := range tt.steps {
+				item := &models.Item{URL: "https://example.com" + s.url, Title: s.title, Content: s.content, Timestamp: time.Now()}
+				written, err := ds.SaveBatch(ItemContext{SiteID: siteID}, []*models.Item{item})
+				if err != nil {
+					t.Fatalf("第 %d 步保存失败: %v", i+1, err)
+				}
+				if written == 1 != s.written {
+					t.Fatalf("第 %d 步写入 %d 条，期望写入 = %v", i+1, written, s.written)
+				}
+			}
+
+			stats, err := repos.Items.Stats()
+			if err != nil {
+				t.Fatalf("统计失败: %v", err)
+			}
+			if stats.Total != tt.rows {
+				t.Fatalf("共 %d 条数据，期望 %d 条", stats.Total, tt.rows)
+			}
+		})
+	}
+}
+
+func TestDatabaseStorageUnchangedKeepsCrawlTime(t *testing.T) {
+	ds, repos, siteID := newTestDatabase(t, config.FiltersConfig{EnableDeduplication: true, DedupField: constants.DedupFieldURL})
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	item := &models.Item{URL: "https://example.com/a", Title: "标题", Content: "正文", Timestamp: first}
+	if err := ds.Save(ItemContext{SiteID: siteID}, item); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	item.Timestamp = first.Add(time.Hour)
+	if err := ds.Save(ItemContext{SiteID: siteID}, item); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	items, _, err := repos.Items.List(repository.ItemFilter{SiteID: siteID})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("查询数据: %d 条 err=%v", len(items), err)
+	}
+	got := items[0]
+	if got.ChangeStatus != constants.ChangeStatusUnchanged || !got.CrawlTime.Equal(first) ||
+		got.CheckedAt == nil || !got.CheckedAt.Equal(item.Timestamp) {
+		t.Fatalf("change=%s crawl_time=%v checked_at=%v", got.ChangeStatus, got.CrawlTime, got.CheckedAt)
+	}
+}
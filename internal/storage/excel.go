@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/export"
+	"example.com/m/v2/pkg/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// NewExcelStorage 创建 xlsx 文件存储。行数据通过流式写入器写出，关闭或轮转时才保存为完整的文件
+func NewExcelStorage(cfg config.StorageConfig) (*FileStorage, error) {
+	// 表头占用一行
+	return newFileStorage(cfg, "xlsx", excelize.TotalRows-1, openExcel)
+}
+
+// excelWriter xlsx 文件写入器
+type excelWriter struct {
+	path string
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func openExcel(path string) (fileWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]interface{}, len(fileColumns))
+	for i, name := range export.Header(fileColumns) {
+		header[i] = name
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &excelWriter{path: path, file: file, sw: sw, row: 1}, nil
+}
+
+func (w *excelWriter) write(ic ItemContext, item *models.Item) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.sw.SetRow(cell, recordValues(ic, item))
+}
+
+// flush xlsx 只能在关闭时整体写出
+func (w *excelWriter) flush() error {
+	return nil
+}
+
+func (w *excelWriter) close() error {
+	defer w.file.Close()
+	if err := w.sw.Flush(); err != nil {
+		return err
+	}
+	return w.file.SaveAs(w.path)
+}
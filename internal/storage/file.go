@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/internal/export"
+	"example.com/m/v2/pkg/models"
+)
+
+// fileRecord 文件中的一条数据，在数据项字段之外记录所属站点和任务
+type fileRecord struct {
+	SiteID int `json:"site_id"`
+	TaskID int `json:"task_id,omitempty"`
+	*models.Item
+}
+
+// fileColumns 表格类文件（CSV、Excel）的列，格式与数据导出接口一致
+var fileColumns = mustSelect(
+	"site_id", "task_id", "url", "title", "content", "description", "author", "source", "language",
+	"publish_date", "crawl_time", "keywords", "tags", "links", "images", "videos", "metadata",
+	"view_count", "comment_count", "like_count", "share_count", "status",
+)
+
+// mustSelect 按名称选取导出列，名称有误时 panic
+func mustSelect(names ...string) []export.Column {
+	columns, err := export.Select(names)
+	if err != nil {
+		panic(err)
+	}
+	return columns
+}
+
+// recordValues 返回数据项各列的值
+func recordValues(ic ItemContext, item *models.Item) []interface{} {
+	return export.Row(fileColumns, itemRow(ic, item))
+}
+
+// fileWriter 单个输出文件的写入器
+type fileWriter interface {
+	write(ic ItemContext, item *models.Item) error
+	// flush 将已写入的数据落盘
+	flush() error
+	close() error
+}
+
+// FileStorage 文件存储，数据写入 OutputDir 下的文件，单个文件达到 RotateRows 行后写入新文件。
+// 文件在第一次写入时创建，文件名包含创建时间
+type FileStorage struct {
+	mu      sync.Mutex
+	dir     string
+	ext     string
+	maxRows int
+	open    func(path string) (fileWriter, error)
+
+	current fileWriter
+	rows    int
+}
+
+// newFileStorage 创建文件存储，maxRows 不超过 limit（limit 为 0 时不限制）
+func newFileStorage(cfg config.StorageConfig, ext string, limit int, open func(path string) (fileWriter, error)) (*FileStorage, error) {
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	maxRows := cfg.RotateRows
+	if limit > 0 && (maxRows <= 0 || maxRows > limit) {
+		maxRows = limit
+	}
+
+	return &FileStorage{
+		dir:     cfg.OutputDir,
+		ext:     ext,
+		maxRows: maxRows,
+		open:    open,
+	}, nil
+}
+
+// Save 保存单个数据项
+func (fs *FileStorage) Save(ic ItemContext, item *models.Item) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.write(ic, item); err != nil {
+		return err
+	}
+	return fs.current.flush()
+}
+
+// SaveBatch 批量保存数据项，返回写入的条数。出错时已写入的是 items 中的前若干项
+func (fs *FileStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, item := range items {
+		if err := fs.write(ic, item); err != nil {
+			return i, err
+		}
+	}
+	if fs.current == nil {
+		return 0, nil
+	}
+	return len(items), fs.current.flush()
+}
+
+// Close 关闭当前文件
+func (fs *FileStorage) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.current == nil {
+		return nil
+	}
+	err := fs.current.close()
+	fs.current = nil
+	return err
+}
+
+// write 写入一行，需要时轮转到新文件
+func (fs *FileStorage) write(ic ItemContext, item *models.Item) error {
+	if fs.current != nil && fs.maxRows > 0 && fs.rows >= fs.maxRows {
+		err := fs.current.close()
+		fs.current = nil
+		if err != nil {
+			return fmt.Errorf("关闭输出文件失败: %w", err)
+		}
+	}
+
+	if fs.current == nil {
+		path, err := createOutputFile(fs.dir, fs.ext)
+		if err != nil {
+			return err
+		}
+		w, err := fs.open(path)
+		if err != nil {
+			return fmt.Errorf("打开输出文件失败: %w", err)
+		}
+		fs.current = w
+		fs.rows = 0
+	}
+
+	if err := fs.current.write(ic, item); err != nil {
+		return err
+	}
+	fs.rows++
+	return nil
+}
+
+// createOutputFile 在目录下创建一个新的空文件并返回路径，文件名重复时追加序号
+func createOutputFile(dir, ext string) (string, error) {
+	base := "crawl_data_" + time.Now().Format("20060102_150405")
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%d.%s", base, i, ext))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		return path, f.Close()
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/pkg/models"
+)
+
+// NewJSONLStorage 创建 JSON Lines 文件存储，每行一个数据项
+func NewJSONLStorage(cfg config.StorageConfig) (*FileStorage, error) {
+	return newFileStorage(cfg, "jsonl", 0, openJSONL)
+}
+
+// jsonlWriter JSON Lines 文件写入器
+type jsonlWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+}
+
+func openJSONL(path string) (fileWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(file)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{file: file, buf: buf, enc: enc}, nil
+}
+
+func (w *jsonlWriter) write(ic ItemContext, item *models.Item) error {
+	return w.enc.Encode(fileRecord{SiteID: ic.SiteID, TaskID: ic.TaskID, Item: item})
+}
+
+func (w *jsonlWriter) flush() error {
+	return w.buf.Flush()
+}
+
+func (w *jsonlWriter) close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
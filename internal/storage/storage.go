@@ -1,19 +1,155 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
 	"example.com/m/v2/internal/config"
+	"example.com/m/v2/pkg/constants"
 	"example.com/m/v2/pkg/models"
 )
 
+// ItemContext 数据项所属的站点和任务
+type ItemContext struct {
+	SiteID int // 站点ID
+	TaskID int // 任务ID，为 0 时不关联任务
+}
+
 // Storage 存储接口
 type Storage interface {
-	Save(item *models.Item) error
-	SaveBatch(items []*models.Item) error
+	Save(ic ItemContext, item *models.Item) error
+	// SaveBatch 批量保存数据项，返回实际写入的条数，去重跳过的数据不计入。
+	// 出错时已写入的数据为 items 中的前若干项，条数即返回值
+	SaveBatch(ic ItemContext, items []*models.Item) (int, error)
 	Close() error
 }
 
-// NewStorage 创建存储实例
-func NewStorage(cfg config.StorageConfig) (Storage, error) {
-	// 移除了文件和Excel存储后，只返回数据库存储
-	return NewDatabaseStorage(cfg)
+// Factory 根据配置创建存储实例
+type Factory func(cfg *config.Config) (Storage, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		constants.StorageTypeDatabase: func(cfg *config.Config) (Storage, error) {
+			return NewDatabaseStorage(cfg.Storage, cfg.Filters)
+		},
+		constants.StorageTypeJSON:  func(cfg *config.Config) (Storage, error) { return NewJSONLStorage(cfg.Storage) },
+		constants.StorageTypeCSV:   func(cfg *config.Config) (Storage, error) { return NewCSVStorage(cfg.Storage) },
+		constants.StorageTypeExcel: func(cfg *config.Config) (Storage, error) { return NewExcelStorage(cfg.Storage) },
+	}
+)
+
+// legacyTypes 兼容旧配置的存储类型及其对应的类型。旧版本不论 type 为何都只写入数据库，
+// 示例配置中的 "file" 因此继续写入数据库，同时写出 JSONL 文件
+var legacyTypes = map[string][]string{
+	constants.StorageTypeFile: {constants.StorageTypeDatabase, constants.StorageTypeJSON},
+}
+
+// Register 注册存储类型，同名时覆盖已有的类型
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// NewStorage 按配置的存储类型创建存储实例。Type 为逗号分隔的多个类型时，
+// 返回同时写入所有类型的 MultiStorage；旧配置的类型按 legacyTypes 展开
+func NewStorage(cfg *config.Config) (Storage, error) {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(cfg.Storage.Type, ",") {
+		name = strings.TrimSpace(name)
+		expanded, ok := legacyTypes[name]
+		if !ok {
+			expanded = []string{name}
+		}
+		for _, name := range expanded {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{constants.StorageTypeDatabase}
+	}
+
+	var stores []Storage
+	for _, name := range names {
+		factoriesMu.RLock()
+		factory, ok := factories[name]
+		factoriesMu.RUnlock()
+
+		var store Storage
+		var err error
+		if !ok {
+			err = fmt.Errorf("不支持的存储类型: %s", name)
+		} else {
+			store, err = factory(cfg)
+		}
+		if err != nil {
+			// 关闭已创建的存储
+			for _, s := range stores {
+				s.Close()
+			}
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+
+	if len(stores) == 1 {
+		return stores[0], nil
+	}
+	return NewMultiStorage(stores...), nil
+}
+
+// MultiStorage 将数据同时写入多个存储。某个存储失败时仍写入其余存储，并返回合并后的错误
+type MultiStorage struct {
+	stores []Storage
+}
+
+// NewMultiStorage 创建组合存储
+func NewMultiStorage(stores ...Storage) *MultiStorage {
+	return &MultiStorage{stores: stores}
+}
+
+// Save 保存单个数据项
+func (ms *MultiStorage) Save(ic ItemContext, item *models.Item) error {
+	var errs []error
+	for _, store := range ms.stores {
+		if err := store.Save(ic, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SaveBatch 批量保存数据项，写入条数以第一个存储为准（文件存储不去重，条数可能多于数据库）。
+// 各存储的写入结果不同，失败后不能整体重试，BufferedStorage 按存储分别重试
+func (ms *MultiStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	saved := 0
+	var errs []error
+	for i, store := range ms.stores {
+		n, err := store.SaveBatch(ic, items)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if i == 0 {
+			saved = n
+		}
+	}
+	return saved, errors.Join(errs...)
+}
+
+// Close 关闭所有存储
+func (ms *MultiStorage) Close() error {
+	var errs []error
+	for _, store := range ms.stores {
+		if err := store.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
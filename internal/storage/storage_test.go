@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"example.com/m/v2/internal/config"
+	"example.com/m/v2/pkg/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// testItems 返回 n 个测试数据项
+func testItems(n int) []*models.Item {
+	items := make([]*models.Item, n)
+	for i := range items {
+		items[i] = &models.Item{URL: "https://example.com/" + string(rune('a'+i)), Title: "标题", Keywords: []string{"a", "b"}}
+	}
+	return items
+}
+
+// outputFiles 返回目录下指定扩展名的文件，按文件名排序
+func outputFiles(t *testing.T, dir, ext string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*."+ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestNewStorage(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want []string // 各存储的类型，database 或文件扩展名
+	}{
+		{"", []string{"database"}},
+		{"database", []string{"database"}},
+		{"json", []string{"jsonl"}},
+		{"file", []string{"database", "jsonl"}},
+		{"database, file", []string{"database", "jsonl"}},
+		{"csv,excel,csv", []string{"csv", "xlsx"}},
+		{"nope", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := &config.Config{Storage: config.StorageConfig{
+				Type:      tt.typ,
+				OutputDir: dir,
+				Database:  config.DBConfig{Driver: "sqlite3", SQLiteFile: filepath.Join(dir, "test.db")},
+			}}
+			store, err := NewStorage(cfg)
+			if tt.want == nil {
+				if err == nil {
+					store.Close()
+					t.Fatal("不支持的类型应返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("创建存储失败: %v", err)
+			}
+			defer store.Close()
+
+			stores := []Storage{store}
+			if multi, ok := store.(*MultiStorage); ok {
+				stores = multi.stores
+			}
+			var got []string
+			for _, s := range stores {
+				switch s := s.(type) {
+				case *DatabaseStorage:
+					got = append(got, "database")
+				case *FileStorage:
+					got = append(got, s.ext)
+				default:
+					t.Fatalf("未知的存储 %T", s)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("存储 = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLStorageRotates(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewJSONLStorage(config.StorageConfig{OutputDir: dir, RotateRows: 2})
+	if err != nil {
+		t.Fatalf("创建存储失败: %v", err)
+	}
+
+	ic := ItemContext{SiteID: 3, TaskID: 7}
+	items := testItems(5)
+	if n, err := store.SaveBatch(ic, items[:4]); err != nil || n != 4 {
+		t.Fatalf("批量保存: n=%d err=%v", n, err)
+	}
+	if err := store.Save(ic, items[4]); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	files := outputFiles(t, dir, "jsonl")
+	if len(files) != 3 {
+		t.Fatalf("文件数 = %d，期望 3", len(files))
+	}
+	var urls []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var record struct {
+				SiteID int    `json:"site_id"`
+				TaskID int    `json:"task_id"`
+				URL    string `json:"url"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("解析 %s 失败: %v", path, err)
+			}
+			if record.SiteID != 3 || record.TaskID != 7 {
+				t.Fatalf("站点或任务不一致: %+v", record)
+			}
+			urls = append(urls, record.URL)
+		}
+	}
+	for i, item := range items {
+		if i >= len(urls) || urls[i] != item.URL {
+			t.Fatalf("写出的URL = %v", urls)
+		}
+	}
+}
+
+func TestTableStorages(t *testing.T) {
+	tests := []struct {
+		ext  string
+		open func(cfg config.StorageConfig) (*FileStorage, error)
+		rows func(t *testing.T, path string) [][]string
+	}{
+		{"csv", NewCSVStorage, func(t *testing.T, path string) [][]string {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")) {
+				t.Fatal("CSV 应以 UTF-8 BOM 开头")
+			}
+			records, err := csv.NewReader(bytes.NewReader(data[3:])).ReadAll()
+			if err != nil {
+				t.Fatalf("解析CSV失败: %v", err)
+			}
+			return records
+		}},
+		{"xlsx", NewExcelStorage, func(t *testing.T, path string) [][]string {
+			f, err := excelize.OpenFile(path)
+			if err != nil {
+				t.Fatalf("打开Excel失败: %v", err)
+			}
+			defer f.Close()
+			rows, err := f.GetRows(f.GetSheetName(0))
+			if err != nil {
+				t.Fatalf("读取Excel行失败: %v", err)
+			}
+			return rows
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := tt.open(config.StorageConfig{OutputDir: dir})
+			if err != nil {
+				t.Fatalf("创建存储失败: %v", err)
+			}
+			items := testItems(2)
+			items[1].Title = "=1+1"
+			if n, err := store.SaveBatch(ItemContext{SiteID: 1}, items); err != nil || n != 2 {
+				t.Fatalf("批量保存: n=%d err=%v", n, err)
+			}
+			if err := store.Close(); err != nil {
+				t.Fatalf("关闭失败: %v", err)
+			}
+
+			files := outputFiles(t, dir, tt.ext)
+			if len(files) != 1 {
+				t.Fatalf("文件数 = %d，期望 1", len(files))
+			}
+			rows := tt.rows(t, files[0])
+			if len(rows) != 3 || rows[0][0] != "site_id" || rows[0][3] != "title" {
+				t.Fatalf("行 = %q", rows)
+			}
+			if rows[1][2] != items[0].URL || rows[1][3] != "标题" || rows[2][3] != "'=1+1" {
+				t.Fatalf("数据行 = %q", rows[1:])
+			}
+			if keywords := rows[1][11]; keywords != "a; b" {
+				t.Fatalf("keywords = %q", keywords)
+			}
+		})
+	}
+}
+
+// failingStorage 写入指定条数后失败的存储
+type failingStorage struct {
+	limit int
+	saved []*models.Item
+}
+
+var errStorageFull = errors.New("storage full")
+
+func (s *failingStorage) Save(ic ItemContext, item *models.Item) error {
+	_, err := s.SaveBatch(ic, []*models.Item{item})
+	return err
+}
+
+func (s *failingStorage) SaveBatch(ic ItemContext, items []*models.Item) (int, error) {
+	for i, item := range items {
+		if len(s.saved) >= s.limit {
+			return i, errStorageFull
+		}
+		s.saved = append(s.saved, item)
+	}
+	return len(items), nil
+}
+
+func (s *failingStorage) Close() error {
+	return nil
+}
+
+func TestMultiStorage(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits []int
+		saved  int
+		err    bool
+	}{
+		{"all ok", []int{10, 10}, 3, false},
+		{"second fails", []int{10, 1}, 3, true},
+		{"first fails", []int{2, 10}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stores []Storage
+			var sinks []*failingStorage
+			for _, limit := range tt.limits {
+				sink := &failingStorage{limit: limit}
+				sinks = append(sinks, sink)
+				stores = append(stores, sink)
+			}
+
+			saved, err := NewMultiStorage(stores...).SaveBatch(ItemContext{SiteID: 1}, testItems(3))
+			if saved != tt.saved || (err != nil) != tt.err {
+				t.Fatalf("saved=%d err=%v，期望 saved=%d err=%v", saved, err, tt.saved, tt.err)
+			}
+			if tt.err && !errors.Is(err, errStorageFull) {
+				t.Fatalf("错误 = %v", err)
+			}
+			// 某个存储失败时其余存储照常写入
+			for i, sink := range sinks {
+				if want := min(tt.limits[i], 3); len(sink.saved) != want {
+					t.Fatalf("存储 %d 写入 %d 条，期望 %d 条", i, len(sink.saved), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"example.com/m/v2/internal/repository"
+)
+
+const (
+	taskLogBatchSize     = 50          // 单次写入的最大条数
+	taskLogMaxPending    = 5000        // 待写入日志上限，超出后丢弃
+	taskLogFlushInterval = time.Second // 定时写入间隔
+)
+
+// TaskLogger 任务日志记录器，通过日志存取接口批量写入任务日志，同时输出到基础日志记录器
+type TaskLogger struct {
+	logs   repository.LogRepository
+	taskID int
+	base   Logger
+	level  LogLevel
+
+	mu      sync.Mutex
+	pending []repository.TaskLog
+	dropped int
+
+	flushCh   chan struct{}
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewTaskLogger 创建任务日志记录器，低于 level 的日志不写入数据库
+func NewTaskLogger(logs repository.LogRepository, taskID int, base Logger, level LogLevel) *TaskLogger {
+	l := &TaskLogger{
+		logs:    logs,
+		taskID:  taskID,
+		base:    base,
+		level:   level,
+		flushCh: make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+	}
+
+	l.wg.Add(1)
+	go l.loop()
+
+	return l
+}
+
+// Info 记录信息日志
+func (l *TaskLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.base.Info(msg, l.withTaskID(keysAndValues)...)
+	l.record(LogLevelInfo, "info", msg, keysAndValues)
+}
+
+// Error 记录错误日志
+func (l *TaskLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.base.Error(msg, l.withTaskID(keysAndValues)...)
+	l.record(LogLevelError, "error", msg, keysAndValues)
+}
+
+// Warn 记录警告日志
+func (l *TaskLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.base.Warn(msg, l.withTaskID(keysAndValues)...)
+	l.record(LogLevelWarn, "warn", msg, keysAndValues)
+}
+
+// Debug 记录调试日志
+func (l *TaskLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.base.Debug(msg, l.withTaskID(keysAndValues)...)
+	l.record(LogLevelDebug, "debug", msg, keysAndValues)
+}
+
+// Close 写入剩余日志并停止后台写入
+func (l *TaskLogger) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closeCh)
+	})
+	l.wg.Wait()
+	return nil
+}
+
+// record 将日志加入待写入队列
+func (l *TaskLogger) record(level LogLevel, levelName, msg string, keysAndValues []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	entry := repository.TaskLog{
+		TaskID:    l.taskID,
+		Level:     levelName,
+		Message:   msg,
+		Details:   string(buildLogDetails(keysAndValues)),
+		CreatedAt: time.Now(),
+	}
+
+	l.mu.Lock()
+	if len(l.pending) >= taskLogMaxPending {
+		l.dropped++
+		l.mu.Unlock()
+		return
+	}
+	l.pending = append(l.pending, entry)
+	full := len(l.pending) >= taskLogBatchSize
+	l.mu.Unlock()
+
+	if full {
+		select {
+		case l.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// loop 后台按数量或时间间隔写入日志
+func (l *TaskLogger) loop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(taskLogFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-l.flushCh:
+			l.flush()
+		case <-l.closeCh:
+			l.flush()
+			return
+		}
+	}
+}
+
+// flush 将待写入日志分批写入数据库
+func (l *TaskLogger) flush() {
+	l.mu.Lock()
+	entries := l.pending
+	dropped := l.dropped
+	l.pending = nil
+	l.dropped = 0
+	l.mu.Unlock()
+
+	if dropped > 0 {
+		l.base.Warn("任务日志过多，部分日志未写入数据库", "task_id", l.taskID, "dropped", dropped)
+	}
+
+	for len(entries) > 0 {
+		n := len(entries)
+		if n > taskLogBatchSize {
+			n = taskLogBatchSize
+		}
+		if err := l.logs.CreateBatch(entries[:n]); err != nil {
+			l.base.Error("写入任务日志失败", "task_id", l.taskID, "count", n, "error", err)
+		}
+		entries = entries[n:]
+	}
+}
+
+// withTaskID 为输出到基础日志记录器的字段追加任务ID
+func (l *TaskLogger) withTaskID(keysAndValues []interface{}) []interface{} {
+	fields := make([]interface{}, 0, len(keysAndValues)+2)
+	fields = append(fields, keysAndValues...)
+	return append(fields, "task_id", l.taskID)
+}
+
+// buildLogDetails 将键值对转换为 JSON 对象，没有字段时返回 nil
+func buildLogDetails(keysAndValues []interface{}) []byte {
+	if len(keysAndValues) < 2 {
+		return nil
+	}
+
+	details := make(map[string]interface{}, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		switch value := keysAndValues[i+1].(type) {
+		case error:
+			details[key] = value.Error()
+		case fmt.Stringer:
+			details[key] = value.String()
+		default:
+			details[key] = value
+		}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		// 存在无法序列化的值时退化为字符串
+		for key, value := range details {
+			details[key] = fmt.Sprint(value)
+		}
+		data, _ = json.Marshal(details)
+	}
+	return data
+}
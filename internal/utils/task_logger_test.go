@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"example.com/m/v2/internal/repository"
+)
+
+// nopLogger 丢弃所有日志
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Debug(string, ...interface{}) {}
+
+func TestTaskLoggerWritesThroughRepository(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	logger := NewTaskLogger(repos.Logs, 7, nopLogger{}, LogLevelInfo)
+
+	logger.Debug("调试信息")
+	logger.Info("访问页面", "url", "https://example.com/", "depth", 1)
+	logger.Warn("没有字段")
+	logger.Error("请求失败", "error", errors.New("超时"))
+	for i := 0; i < taskLogBatchSize; i++ {
+		logger.Info("批量")
+	}
+	logger.Close()
+
+	logs, err := repos.Logs.List(repository.LogFilter{TaskID: 7})
+	if err != nil {
+		t.Fatalf("查询日志失败: %v", err)
+	}
+	if len(logs) != 3+taskLogBatchSize {
+		t.Fatalf("写入 %d 条日志，期望 %d 条（debug 级别不写入）", len(logs), 3+taskLogBatchSize)
+	}
+
+	byMessage := map[string]repository.TaskLog{}
+	for _, log := range logs {
+		byMessage[log.Message] = log
+	}
+
+	info := byMessage["访问页面"]
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(info.Details), &details); err != nil {
+		t.Fatalf("日志详情不是 JSON: %q", info.Details)
+	}
+	if info.Level != "info" || details["url"] != "https://example.com/" || details["depth"] != float64(1) {
+		t.Fatalf("日志内容不符: %+v", info)
+	}
+	if warn := byMessage["没有字段"]; warn.Level != "warn" || warn.Details != "" {
+		t.Fatalf("没有字段的日志不应有详情: %+v", warn)
+	}
+	if e := byMessage["请求失败"]; e.Level != "error" || e.Details != `{"error":"超时"}` {
+		t.Fatalf("错误日志的详情不符: %+v", e)
+	}
+}
@@ -48,6 +48,20 @@ const (
 	ItemStatusSkipped   = "skipped"
 )
 
+// 数据变更状态常量，记录重复抓取时内容是否变化
+const (
+	ChangeStatusNew       = "new"
+	ChangeStatusUpdated   = "updated"
+	ChangeStatusUnchanged = "unchanged"
+)
+
+// 去重字段常量
+const (
+	DedupFieldURL     = "url"
+	DedupFieldTitle   = "title"
+	DedupFieldContent = "content"
+)
+
 // HTTP 常量
 const (
 	UserAgentChrome  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
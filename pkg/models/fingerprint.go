@@ -0,0 +1,88 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// simHashShingle SimHash 分词时每个片段的字符数
+const simHashShingle = 3
+
+// NormalizeText 规范化文本：转为小写，标点和空白统一为单个空格。
+// 只有格式差异的两段文本规范化后相同
+func NormalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// fingerprintText 参与内容指纹计算的文本：标题、描述和正文
+func (i *Item) fingerprintText() string {
+	return NormalizeText(i.Title) + "\n" + NormalizeText(i.Description) + "\n" + NormalizeText(i.Content)
+}
+
+// ContentHash 返回规范化后标题、描述和正文的 SHA-256 十六进制哈希
+func (i *Item) ContentHash() string {
+	sum := sha256.Sum256([]byte(i.fingerprintText()))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash 返回内容的 64 位 SimHash，内容相近的数据项汉明距离较小。
+// 按字符切分为重叠片段，不依赖分词，中英文均适用；没有文本时返回 0
+func (i *Item) SimHash() uint64 {
+	text := i.fingerprintText()
+	if strings.TrimSpace(text) == "" {
+		return 0
+	}
+	runes := []rune(text)
+
+	var weights [64]int
+	add := func(shingle []rune) {
+		h := fnv.New64a()
+		h.Write([]byte(string(shingle)))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(runes) <= simHashShingle {
+		add(runes)
+	} else {
+		for start := 0; start+simHashShingle <= len(runes); start++ {
+			add(runes[start : start+simHashShingle])
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// HammingDistance 返回两个 SimHash 不同的位数
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
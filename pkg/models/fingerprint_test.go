@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello,  World!", "hello world"},
+		{"  前后空白\n换行\t制表 ", "前后空白 换行 制表"},
+		{"——标点——", "标点"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeText(tt.in); got != tt.want {
+			t.Errorf("NormalizeText(%q) = %q，期望 %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	base := &Item{Title: "标题", Description: "描述", Content: "Go 语言 爬虫"}
+	tests := []struct {
+		name string
+		item *Item
+		same bool
+	}{
+		{"格式差异", &Item{Title: " 标题 ", Description: "描述。", Content: "go  语言\n爬虫"}, true},
+		{"URL和时间不参与", &Item{URL: "https://example.com", Author: "作者", Title: "标题", Description: "描述", Content: "Go 语言 爬虫"}, true},
+		{"正文变化", &Item{Title: "标题", Description: "描述", Content: "Go 语言 框架"}, false},
+		{"字段互换", &Item{Title: "描述", Description: "标题", Content: "Go 语言 爬虫"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.item.ContentHash() == base.ContentHash(); got != tt.same {
+			t.Errorf("%s: 哈希相同 = %v，期望 %v", tt.name, got, tt.same)
+		}
+	}
+	if len(base.ContentHash()) != 64 {
+		t.Fatalf("哈希长度 = %d", len(base.ContentHash()))
+	}
+}
+
+func TestSimHash(t *testing.T) {
+	text := strings.Repeat("网络爬虫按站点配置抓取页面并提取标题和正文内容，", 6)
+	base := &Item{Title: "标题", Content: text}
+
+	tests := []struct {
+		name string
+		item *Item
+		max  int // 与 base 的最大汉明距离，为 -1 时期望距离较大
+	}{
+		{"相同内容", &Item{Title: "标题", Content: text}, 0},
+		{"格式差异", &Item{Title: "标题", Content: strings.ReplaceAll(text, "，", "。 ")}, 0},
+		{"少量修改", &Item{Title: "标题", Content: strings.Replace(text, "抓取", "下载", 1)}, 3},
+		{"不同内容", &Item{Title: "另一篇", Content: "The quick brown fox jumps over the lazy dog again and again."}, -1},
+	}
+	for _, tt := range tests {
+		d := HammingDistance(base.SimHash(), tt.item.SimHash())
+		if tt.max >= 0 && d > tt.max || tt.max < 0 && d <= 10 {
+			t.Errorf("%s: 汉明距离 = %d", tt.name, d)
+		}
+	}
+
+	if h := (&Item{Title: " ，"}).SimHash(); h != 0 {
+		t.Fatalf("空内容的 SimHash = %x，期望 0", h)
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xFF, 0x0F, 4},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%x, %x) = %d，期望 %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -123,16 +123,16 @@ func (i *Item) IsValid() bool {
 	return i.URL != "" && i.Title != ""
 }
 
-// GetHash 获取内容哈希
+// GetHash 获取内容哈希，见 ContentHash
 func (i *Item) GetHash() string {
-	// 可以使用URL和标题生成哈希
-	return i.URL + "|" + i.Title
+	return i.ContentHash()
 }
 
 // CrawlTask 定义了一个独立的、可传递的爬虫任务。
 // 它用于解耦API层和Crawler层。
 type CrawlTask struct {
-	ID        int
+	ID        int // 任务ID（对应 tasks 表）
+	SiteID    int // 站点ID
 	Name      string
 	BaseURL   string
 	StartURLs []string
@@ -142,10 +142,14 @@ type CrawlTask struct {
 
 // CrawlTaskRules 定义了任务特定的爬取规则。
 type CrawlTaskRules struct {
-	MaxDepth   int
-	MaxPages   int
-	Concurrent int
-	Delay      int
+	MaxDepth         int      `json:"max_depth"`
+	MaxPages         int      `json:"max_pages"`
+	Concurrent       int      `json:"concurrent"`
+	Delay            int      `json:"delay"`
+	URLPatterns      []string `json:"url_patterns"`      // 包含规则（正则）
+	ExcludePatterns  []string `json:"exclude_patterns"`  // 排除规则（正则）
+	ForbiddenDomains []string `json:"forbidden_domains"` // 禁止的域名
+	RespectRobots    *bool    `json:"respect_robots"`    // 是否遵守robots.txt，未设置时使用全局配置
 }
 
 // Comment 评论模型